@@ -0,0 +1,192 @@
+package overflow
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"github.com/onflow/flowkit/v2/accounts"
+	"github.com/pkg/errors"
+)
+
+// OverflowAccountKeyBuilder the options used when adding or revoking a key
+type OverflowAccountKeyBuilder struct {
+	Weight        int
+	HashAlgorithm crypto.HashAlgorithm
+	// the account that signs the transaction, defaults to the account as it is in flow.json
+	Signer *accounts.Account
+}
+
+// OverflowAccountKeyOption an option when adding or revoking a key
+type OverflowAccountKeyOption func(*OverflowAccountKeyBuilder)
+
+// the weight of the added key, the default is 1000 so the key can sign alone
+func WithKeyWeight(weight int) OverflowAccountKeyOption {
+	return func(b *OverflowAccountKeyBuilder) {
+		b.Weight = weight
+	}
+}
+
+// the hash algorithm of the added key, the default is SHA3_256
+func WithKeyHashAlgorithm(algorithm crypto.HashAlgorithm) OverflowAccountKeyOption {
+	return func(b *OverflowAccountKeyBuilder) {
+		b.HashAlgorithm = algorithm
+	}
+}
+
+// sign the key transaction with this account instead of the one in flow.json, IE with a key added by AddKey
+func WithKeySigner(signer *accounts.Account) OverflowAccountKeyOption {
+	return func(b *OverflowAccountKeyBuilder) {
+		b.Signer = signer
+	}
+}
+
+func (o *OverflowState) accountKeyBuilder(accountName string, opts []OverflowAccountKeyOption) (*OverflowAccountKeyBuilder, error) {
+	account, err := o.accountByName(accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := &OverflowAccountKeyBuilder{
+		Weight:        1000,
+		HashAlgorithm: crypto.SHA3_256,
+		Signer:        account,
+	}
+	for _, opt := range opts {
+		opt(builder)
+	}
+	return builder, nil
+}
+
+// AddKey add the public key of the private key to the account with the given name.
+// The returned account signs with the new key and can be used with WithManualSigner, IE to rotate the key of an account
+//
+//	rotated, _ := o.AddKey(ctx, "first", newPrivateKey)
+//	o.RevokeKey(ctx, "first", 0, WithKeySigner(rotated))
+func (o *OverflowState) AddKey(ctx context.Context, accountName string, privateKey crypto.PrivateKey, opts ...OverflowAccountKeyOption) (*accounts.Account, error) {
+	builder, err := o.accountKeyBuilder(accountName, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.addKeys(ctx, builder.Signer, privateKey.PublicKey(), builder.HashAlgorithm, builder.Weight, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := o.ListKeys(ctx, accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &accounts.Account{
+		Name:    builder.Signer.Name,
+		Address: builder.Signer.Address,
+		Key:     accounts.NewHexKeyFromPrivateKey(keys[len(keys)-1].Index, builder.HashAlgorithm, privateKey),
+	}, nil
+}
+
+// RevokeKey revoke the key with the given index on the account with the given name
+func (o *OverflowState) RevokeKey(ctx context.Context, accountName string, keyIndex uint32, opts ...OverflowAccountKeyOption) error {
+	builder, err := o.accountKeyBuilder(accountName, opts)
+	if err != nil {
+		return err
+	}
+
+	result := o.Tx(`
+transaction(keyIndex: Int) {
+	prepare(signer: auth(RevokeKey) &Account) {
+		if signer.keys.revoke(keyIndex: keyIndex) == nil {
+			panic("there is no key with index ".concat(keyIndex.toString()))
+		}
+	}
+}`,
+		WithContext(ctx),
+		WithManualSigner(builder.Signer),
+		WithArg("keyIndex", int(keyIndex)),
+		WithoutLog(),
+		WithPanicInteractionOnError(false),
+	)
+	if result.Err != nil {
+		return errors.Wrapf(result.Err, "could not revoke key %d on %s", keyIndex, accountName)
+	}
+	return nil
+}
+
+// ListKeys the keys on the account with the given name, including revoked keys
+func (o *OverflowState) ListKeys(ctx context.Context, accountName string) ([]*flow.AccountKey, error) {
+	account, err := o.accountByName(accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	flowAccount, err := o.Flowkit.GetAccount(ctx, account.Address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not fetch account %s", accountName)
+	}
+	return flowAccount.Keys, nil
+}
+
+// add count copies of the public key to the account of the signer
+func (o *OverflowState) addKeys(ctx context.Context, signer *accounts.Account, publicKey crypto.PublicKey, hashAlgorithm crypto.HashAlgorithm, weight int, count int) error {
+	signatureAlgorithm, err := cadenceSignatureAlgorithm(publicKey.Algorithm())
+	if err != nil {
+		return err
+	}
+
+	result := o.Tx(`
+transaction(publicKey: String, signatureAlgorithm: UInt8, hashAlgorithm: UInt8, weight: UFix64, count: Int) {
+	prepare(signer: auth(AddKey) &Account) {
+		let key = PublicKey(publicKey: publicKey.decodeHex(), signatureAlgorithm: SignatureAlgorithm(rawValue: signatureAlgorithm)!)
+		var i = 0
+		while i < count {
+			signer.keys.add(publicKey: key, hashAlgorithm: HashAlgorithm(rawValue: hashAlgorithm)!, weight: weight)
+			i = i + 1
+		}
+	}
+}`,
+		WithContext(ctx),
+		WithManualSigner(signer),
+		WithArg("publicKey", hex.EncodeToString(publicKey.Encode())),
+		WithArg("signatureAlgorithm", signatureAlgorithm),
+		// the hash algorithms in cadence have the same raw values as in go
+		WithArg("hashAlgorithm", uint8(hashAlgorithm)),
+		WithArg("weight", float64(weight)),
+		WithArg("count", count),
+		WithoutLog(),
+		WithPanicInteractionOnError(false),
+	)
+	if result.Err != nil {
+		return errors.Wrapf(result.Err, "could not add keys to %s", signer.Address.HexWithPrefix())
+	}
+	return nil
+}
+
+// find an account by name with or without the network prefix
+func (o *OverflowState) accountByName(accountName string) (*accounts.Account, error) {
+	account, err := o.AccountE(accountName)
+	if err == nil {
+		return account, nil
+	}
+	account, rawErr := o.State.Accounts().ByName(accountName)
+	if rawErr != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// the raw value of the signature algorithm in cadence
+func cadenceSignatureAlgorithm(algorithm crypto.SignatureAlgorithm) (uint8, error) {
+	switch algorithm {
+	case crypto.ECDSA_P256:
+		return 1, nil
+	case crypto.ECDSA_secp256k1:
+		return 2, nil
+	case crypto.BLS_BLS12_381:
+		return 3, nil
+	default:
+		return 0, fmt.Errorf("signature algorithm %s is not supported in cadence", algorithm)
+	}
+}
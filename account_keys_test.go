@@ -0,0 +1,66 @@
+package overflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountKeys(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	ctx := context.Background()
+	newKey := func(t *testing.T) crypto.PrivateKey {
+		seed := make([]byte, crypto.MinSeedLength)
+		for i := range seed {
+			seed[i] = byte(i + len(t.Name()))
+		}
+		privateKey, err := crypto.GeneratePrivateKey(crypto.ECDSA_P256, seed)
+		require.NoError(t, err)
+		return privateKey
+	}
+
+	t.Run("Rotate key", func(t *testing.T) {
+		rotated, err := o.AddKey(ctx, "first", newKey(t))
+		require.NoError(t, err)
+		assert.Equal(t, uint32(1), rotated.Key.Index())
+
+		err = o.RevokeKey(ctx, "first", 0, WithKeySigner(rotated))
+		require.NoError(t, err)
+
+		keys, err := o.ListKeys(ctx, "first")
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.True(t, keys[0].Revoked)
+		assert.False(t, keys[1].Revoked)
+
+		o.Tx("transaction { prepare(signer: &Account) {} }", WithManualSigner(rotated)).AssertSuccess(t)
+		o.Tx("transaction { prepare(signer: &Account) {} }", WithSigner("first")).AssertFailure(t, "invalid proposal key")
+	})
+
+	t.Run("Add key with weight and hash algorithm", func(t *testing.T) {
+		_, err := o.AddKey(ctx, "second", newKey(t), WithKeyWeight(500), WithKeyHashAlgorithm(crypto.SHA2_256))
+		require.NoError(t, err)
+
+		keys, err := o.ListKeys(ctx, "second")
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, 500, keys[1].Weight)
+		assert.Equal(t, crypto.SHA2_256, keys[1].HashAlgo)
+	})
+
+	t.Run("Revoke missing key", func(t *testing.T) {
+		err := o.RevokeKey(ctx, "second", 42)
+		assert.ErrorContains(t, err, "there is no key with index 42")
+	})
+
+	t.Run("Unknown account", func(t *testing.T) {
+		_, err := o.ListKeys(ctx, "foobar")
+		assert.Error(t, err)
+	})
+}
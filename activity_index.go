@@ -0,0 +1,208 @@
+package overflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+// OverflowActivity what an address did in a single transaction
+type OverflowActivity struct {
+	TransactionId    string `json:"transactionId"`
+	BlockHeight      uint64 `json:"blockHeight"`
+	TransactionIndex int    `json:"transactionIndex"`
+	// the stakeholder roles the address had in the transaction
+	Roles []string `json:"roles"`
+	// the names of the events in the transaction that had the address in one of its fields
+	EventNames []string `json:"eventNames"`
+}
+
+// OverflowActivityIndex a local index of what addresses did in which transactions, built from the stakeholders of crawled transactions.
+// It is safe to use from multiple goroutines
+type OverflowActivityIndex struct {
+	// the activity for each address sorted by height and transaction index
+	Activity map[string][]OverflowActivity `json:"activity"`
+
+	// the next height to index, the index has seen all blocks before this
+	NextHeight uint64 `json:"nextHeight"`
+
+	// if set the index is persisted to this file when saved
+	FileName string `json:"-"`
+
+	mutex sync.RWMutex
+}
+
+// NewActivityIndex create an empty in memory activity index
+func NewActivityIndex() *OverflowActivityIndex {
+	return &OverflowActivityIndex{
+		Activity: map[string][]OverflowActivity{},
+	}
+}
+
+// LoadActivityIndex load an activity index from the given file, if the file does not exist an empty index is created that is saved to it
+func LoadActivityIndex(fileName string) (*OverflowActivityIndex, error) {
+	index := NewActivityIndex()
+	index.FileName = fileName
+
+	present, err := exists(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if !present {
+		return index, nil
+	}
+
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read activity index %s", fileName)
+	}
+
+	err = json.Unmarshal(content, index)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse activity index %s", fileName)
+	}
+	if index.Activity == nil {
+		index.Activity = map[string][]OverflowActivity{}
+	}
+	return index, nil
+}
+
+// Add the activity of all stakeholders in the transaction to the index, the BlockHeight of the transaction must be set like it is when crawling
+func (i *OverflowActivityIndex) Add(tx OverflowTransaction) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	for address, roles := range tx.Stakeholders {
+		activity := OverflowActivity{
+			TransactionId:    tx.Id,
+			BlockHeight:      tx.BlockHeight,
+			TransactionIndex: tx.TransactionIndex,
+			Roles:            roles,
+			EventNames:       []string{},
+		}
+		for _, event := range tx.Events {
+			if slices.Contains(activity.EventNames, event.Name) {
+				continue
+			}
+			for _, addresses := range event.Addresses {
+				if slices.Contains(addresses, address) {
+					activity.EventNames = append(activity.EventNames, event.Name)
+					break
+				}
+			}
+		}
+
+		existing := i.Activity[address]
+		if slices.ContainsFunc(existing, func(a OverflowActivity) bool { return a.TransactionId == tx.Id }) {
+			continue
+		}
+
+		position := sort.Search(len(existing), func(j int) bool {
+			if existing[j].BlockHeight != activity.BlockHeight {
+				return existing[j].BlockHeight > activity.BlockHeight
+			}
+			return existing[j].TransactionIndex > activity.TransactionIndex
+		})
+		i.Activity[address] = slices.Insert(existing, position, activity)
+	}
+
+	if tx.BlockHeight >= i.NextHeight {
+		i.NextHeight = tx.BlockHeight + 1
+	}
+}
+
+// ActivityFor the activity of the address between the two heights, both inclusive, in the order it happened
+func (i *OverflowActivityIndex) ActivityFor(address string, fromHeight uint64, toHeight uint64) []OverflowActivity {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	result := []OverflowActivity{}
+	for _, activity := range i.Activity[address] {
+		if activity.BlockHeight >= fromHeight && activity.BlockHeight <= toHeight {
+			result = append(result, activity)
+		}
+	}
+	return result
+}
+
+// Addresses all addresses that have activity in the index, sorted
+func (i *OverflowActivityIndex) Addresses() []string {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	addresses := []string{}
+	for address := range i.Activity {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// Save the index to its file, does nothing for in memory indexes
+func (i *OverflowActivityIndex) Save() error {
+	if i.FileName == "" {
+		return nil
+	}
+
+	i.mutex.RLock()
+	content, err := json.Marshal(i)
+	i.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a crash does not leave a broken index behind
+	tmpFile := i.FileName + ".tmp"
+	err = os.WriteFile(tmpFile, content, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "could not write activity index %s", i.FileName)
+	}
+	return os.Rename(tmpFile, i.FileName)
+}
+
+// IndexActivity crawl transactions and add them to the index, then save it.
+// Crawling starts at the NextHeight of the index, use WithCrawlFromHeight to override it
+func (o *OverflowState) IndexActivity(index *OverflowActivityIndex, opts ...OverflowCrawlerOption) error {
+	index.mutex.RLock()
+	nextHeight := index.NextHeight
+	index.mutex.RUnlock()
+
+	allOpts := []OverflowCrawlerOption{WithCrawlFromHeight(nextHeight)}
+	allOpts = append(allOpts, opts...)
+	c := o.crawler(allOpts)
+
+	to, err := c.endHeight()
+	if err != nil {
+		return err
+	}
+
+	if c.FromHeight > to {
+		// the index is already up to date
+		if c.FromHeight == nextHeight {
+			return nil
+		}
+		return fmt.Errorf("cannot crawl from height %d since it is after the end height %d", c.FromHeight, to)
+	}
+
+	err = c.crawlRange(c.FromHeight, to, func(tx OverflowTransaction) error {
+		index.Add(tx)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// blocks without transactions for any address are indexed as well
+	index.mutex.Lock()
+	if to >= index.NextHeight {
+		index.NextHeight = to + 1
+	}
+	index.mutex.Unlock()
+
+	return index.Save()
+}
@@ -0,0 +1,71 @@
+package overflow
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityIndex(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+	o.Tx("arguments", WithSigner("second"), WithArg("test", "foo")).AssertSuccess(t)
+
+	first := "0x179b6b1cb6755e31"
+	second := "0xf3fcd2c1a78f5eee"
+
+	t.Run("Index activity in memory", func(t *testing.T) {
+		index := NewActivityIndex()
+		err := o.IndexActivity(index, WithCrawlFromHeight(6))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(8), index.NextHeight)
+		assert.Contains(t, index.Addresses(), first)
+
+		activity := index.ActivityFor(first, 0, 100)
+		require.Len(t, activity, 1)
+		assert.Equal(t, uint64(6), activity[0].BlockHeight)
+		assert.Contains(t, activity[0].EventNames, "A.0ae53cb6e3f42a79.FlowToken.TokensDeposited")
+		assert.NotEmpty(t, activity[0].TransactionId)
+
+		activity = index.ActivityFor(second, 7, 7)
+		require.Len(t, activity, 1)
+		assert.Contains(t, activity[0].Roles, "authorizer")
+
+		assert.Empty(t, index.ActivityFor(second, 0, 6))
+	})
+
+	t.Run("Index activity is persisted and resumed", func(t *testing.T) {
+		fileName := filepath.Join(t.TempDir(), "activity.json")
+		index, err := LoadActivityIndex(fileName)
+		require.NoError(t, err)
+		require.NoError(t, o.IndexActivity(index, WithCrawlFromHeight(6), WithCrawlToHeight(6)))
+
+		loaded, err := LoadActivityIndex(fileName)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(7), loaded.NextHeight)
+		assert.Len(t, loaded.ActivityFor(first, 0, 100), 1)
+		assert.Empty(t, loaded.ActivityFor(second, 0, 100))
+
+		require.NoError(t, o.IndexActivity(loaded))
+		assert.Equal(t, uint64(8), loaded.NextHeight)
+		assert.Len(t, loaded.ActivityFor(second, 0, 100), 1)
+
+		// up to date so nothing happens
+		require.NoError(t, o.IndexActivity(loaded))
+		assert.Equal(t, uint64(8), loaded.NextHeight)
+	})
+
+	t.Run("Adding the same transaction twice is ignored", func(t *testing.T) {
+		index := NewActivityIndex()
+		tx := OverflowTransaction{Id: "abc", BlockHeight: 3, Stakeholders: map[string][]string{first: {"payer"}}}
+		index.Add(tx)
+		index.Add(tx)
+		assert.Len(t, index.ActivityFor(first, 0, 100), 1)
+		assert.Equal(t, uint64(4), index.NextHeight)
+	})
+}
@@ -0,0 +1,34 @@
+package overflow
+
+import (
+	"github.com/onflow/flowkit/v2"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// IsArchiveFallbackError reports if the given error from an access node means that the data has been pruned or is from a previous spork,
+// access nodes answer with NotFound or OutOfRange for those
+func IsArchiveFallbackError(err error) bool {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if s, ok := status.FromError(e); ok && s.Code() != codes.Unknown && s.Code() != codes.OK {
+			return s.Code() == codes.NotFound || s.Code() == codes.OutOfRange
+		}
+	}
+	return false
+}
+
+// run the given function against Flowkit and retry it against ArchiveFlowkit if it is configured and the primary node does not have the data
+func withArchiveFallback[T any](o *OverflowState, fn func(*flowkit.Flowkit) (T, error)) (T, error) {
+	result, err := fn(o.Flowkit)
+	if err == nil || o.ArchiveFlowkit == nil || !IsArchiveFallbackError(err) {
+		return result, err
+	}
+
+	o.Logger.Debug(errors.Wrap(err, "retrying against archive node").Error())
+	result, archiveErr := fn(o.ArchiveFlowkit)
+	if archiveErr != nil {
+		return result, errors.Wrapf(archiveErr, "archive node fallback failed, primary node error was %v", err)
+	}
+	return result, nil
+}
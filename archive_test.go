@@ -0,0 +1,63 @@
+package overflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hexops/autogold"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestArchiveFallback(t *testing.T) {
+	archive, err := OverflowTesting()
+	require.NoError(t, err)
+	archive.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+	archive.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+
+	t.Run("Should fail without archive node", func(t *testing.T) {
+		_, err := o.GetBlockAtHeight(context.Background(), 7)
+		assert.ErrorContains(t, err, "could not find block at height 7")
+	})
+
+	o.ArchiveFlowkit = archive.Flowkit
+
+	t.Run("Should get block from archive node", func(t *testing.T) {
+		block, err := o.GetBlockAtHeight(context.Background(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(7), block.Height)
+	})
+
+	t.Run("Should get transactions from archive node", func(t *testing.T) {
+		block, err := archive.GetBlockAtHeight(context.Background(), 7)
+		require.NoError(t, err)
+
+		txs, _, err := o.GetTransactionsByBlockId(context.Background(), block.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, txs)
+
+		tx, err := o.GetOverflowTransactionById(context.Background(), txs[0].ID())
+		require.NoError(t, err)
+		assert.Equal(t, block.ID.String(), tx.BlockId)
+	})
+
+	t.Run("Should run script at height against archive node", func(t *testing.T) {
+		o.Script("block", WithExecuteScriptAtBlockHeight(7)).AssertWant(t, autogold.Want("archive height", uint64(7)))
+	})
+
+	t.Run("Should not use archive node for other errors", func(t *testing.T) {
+		assert.False(t, IsArchiveFallbackError(errors.New("invalid signature")))
+		assert.False(t, IsArchiveFallbackError(nil))
+		assert.False(t, IsArchiveFallbackError(errors.New("account not found")))
+		assert.False(t, IsArchiveFallbackError(status.Error(codes.Internal, "could not find block")))
+		assert.True(t, IsArchiveFallbackError(status.Error(codes.OutOfRange, "block height 10 is less than the spork root block height 20")))
+		assert.True(t, IsArchiveFallbackError(fmt.Errorf("fetching block: %w", status.Error(codes.NotFound, "block not found"))))
+	})
+}
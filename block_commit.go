@@ -0,0 +1,159 @@
+package overflow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/pkg/errors"
+)
+
+// the transactions sent to the emulator since the last block was committed
+type overflowPendingBlock struct {
+	transactions []*pendingBlockTransaction
+	// the next sequence number for proposal keys that have been used in this block
+	sequenceNumbers map[string]uint64
+}
+
+// a transaction waiting in the pending block and the interaction it was sent from
+type pendingBlockTransaction struct {
+	builder *OverflowInteractionBuilder
+	result  *OverflowResult
+}
+
+func newPendingBlock() *overflowPendingBlock {
+	return &overflowPendingBlock{
+		transactions:    []*pendingBlockTransaction{},
+		sequenceNumbers: map[string]uint64{},
+	}
+}
+
+func proposalKeyID(key flow.ProposalKey) string {
+	return fmt.Sprintf("%s/%d", key.Address.HexWithPrefix(), key.KeyIndex)
+}
+
+// the sequence number to use for the key, the one on chain does not include transactions in this block
+func (b *overflowPendingBlock) sequenceNumber(key flow.ProposalKey) uint64 {
+	if known, ok := b.sequenceNumbers[proposalKeyID(key)]; ok && known > key.SequenceNumber {
+		return known
+	}
+	return key.SequenceNumber
+}
+
+// the sequence number of the key was used by a transaction sent to this block
+func (b *overflowPendingBlock) useSequenceNumber(key flow.ProposalKey) {
+	b.sequenceNumbers[proposalKeyID(key)] = key.SequenceNumber + 1
+}
+
+func (b *overflowPendingBlock) add(builder *OverflowInteractionBuilder, result *OverflowResult) {
+	b.transactions = append(b.transactions, &pendingBlockTransaction{builder: builder, result: result})
+}
+
+// SetManualBlockCommit stop committing a block for every transaction on the in memory emulator, transactions wait in the pending block until CommitBlock is called.
+// Several transactions then end up in the same block like they do on a real network, IE
+//
+//	o.SetManualBlockCommit(true)
+//	first := o.Tx("mint_tokens", ...)
+//	second := o.Tx("mint_tokens", ...)
+//	results, _ := o.CommitBlock()
+func (o *OverflowState) SetManualBlockCommit(manual bool) error {
+	if o.Emulator == nil {
+		return fmt.Errorf("blocks can only be committed manually on the in memory emulator")
+	}
+
+	unlock := o.lockEmulatorLog()
+	defer unlock()
+
+	if manual {
+		if o.pendingBlock == nil {
+			o.pendingBlock = newPendingBlock()
+		}
+		o.Emulator.DisableAutoMine()
+		return nil
+	}
+
+	if o.pendingBlock != nil && len(o.pendingBlock.transactions) > 0 {
+		return fmt.Errorf("there are %d transactions in the pending block, commit it first", len(o.pendingBlock.transactions))
+	}
+	o.pendingBlock = nil
+	o.Emulator.EnableAutoMine()
+	return nil
+}
+
+// ManualBlockCommit if blocks are committed manually, see SetManualBlockCommit
+func (o *OverflowState) ManualBlockCommit() bool {
+	return o.pendingBlock != nil
+}
+
+// CommitBlock execute the transactions in the pending block and commit it.
+// The results of the transactions are filled in and returned in the order they were sent
+func (o *OverflowState) CommitBlock() ([]*OverflowResult, error) {
+	if o.pendingBlock == nil {
+		return nil, fmt.Errorf("blocks are only committed manually after SetManualBlockCommit")
+	}
+
+	unlock := o.lockEmulatorLog()
+	_, _, err := o.Emulator.ExecuteAndCommitBlock()
+	logMessage, logErr := o.readLog()
+	pendingTransactions := o.pendingBlock.transactions
+	o.pendingBlock = newPendingBlock()
+	unlock()
+
+	if err != nil {
+		err = errors.Wrap(err, "could not commit block")
+		for _, tx := range pendingTransactions {
+			tx.result.Pending = false
+			tx.result.Err = err
+			tx.result.sponsorReservation.settle(nil)
+			o.dropSequenceNumber(tx.result.Transaction.ProposalKey)
+		}
+		return nil, err
+	}
+
+	logs := splitBlockLog(logMessage, len(pendingTransactions))
+	results := []*OverflowResult{}
+	for i, tx := range pendingTransactions {
+		result := tx.result
+		result.Pending = false
+
+		res, err := o.Flowkit.Gateway().GetTransactionResult(tx.builder.Ctx, result.Id, true)
+		if err != nil || res.Error != nil {
+			o.dropSequenceNumber(result.Transaction.ProposalKey)
+		}
+		if err != nil {
+			result.Err = err
+			result.sponsorReservation.settle(nil)
+		} else {
+			result.TransactionResult = res
+			if logErr != nil {
+				result.Err = logErr
+			}
+			result = tx.builder.processResult(result, logs[i])
+		}
+		results = append(results, o.finishTx(tx.builder, result))
+	}
+	return results, nil
+}
+
+// split the log of a block into the log of every transaction in it, the emulator ends the log of every transaction it executes with its execution data
+func splitBlockLog(logMessage []OverflowEmulatorLogMessage, transactions int) [][]OverflowEmulatorLogMessage {
+	logs := make([][]OverflowEmulatorLogMessage, transactions)
+	index := 0
+	for _, msg := range logMessage {
+		if index == transactions {
+			break
+		}
+		logs[index] = append(logs[index], msg)
+		if strings.Contains(msg.Msg, "transaction execution data") {
+			index++
+		}
+	}
+	return logs
+}
+
+// commit blocks manually on the in memory emulator, see SetManualBlockCommit
+func WithManualBlockCommit() OverflowOption {
+	return func(o *OverflowBuilder) {
+		o.ManualBlockCommit = true
+	}
+}
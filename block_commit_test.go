@@ -0,0 +1,128 @@
+package overflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManualBlockCommit(t *testing.T) {
+	o, err := OverflowTesting(WithManualBlockCommit())
+	require.NoError(t, err)
+	require.NotNil(t, o)
+	assert.True(t, o.ManualBlockCommit())
+
+	height := func(t *testing.T) uint64 {
+		block, err := o.GetLatestBlock(context.Background())
+		require.NoError(t, err)
+		return block.Height
+	}
+
+	mint := func(recipient string, amount float64) *OverflowResult {
+		return o.Tx("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", recipient),
+			WithArg("amount", amount),
+		)
+	}
+
+	t.Run("Run several transactions in one block", func(t *testing.T) {
+		before := height(t)
+
+		first := mint("first", 1.0)
+		second := mint("second", 2.0)
+		require.NoError(t, first.Err)
+		assert.True(t, first.Pending)
+		assert.True(t, second.Pending)
+		assert.Empty(t, first.Events)
+		assert.Equal(t, before, height(t))
+
+		results, err := o.CommitBlock()
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, before+1, height(t))
+
+		assert.Same(t, first, results[0])
+		first.AssertSuccess(t).AssertEvent(t, "TokensMinted", map[string]interface{}{"amount": 1.0})
+		second.AssertSuccess(t).AssertEvent(t, "TokensMinted", map[string]interface{}{"amount": 2.0})
+		assert.False(t, first.Pending)
+		assert.Equal(t, first.TransactionResult.BlockID, second.TransactionResult.BlockID)
+		assert.Greater(t, first.ComputationUsed, 0)
+		assert.NotEqual(t, first.Meter, &OverflowMeter{})
+	})
+
+	t.Run("Failing transaction in block", func(t *testing.T) {
+		ok := mint("first", 1.0)
+		failing := o.Tx(`transaction { prepare(signer: &Account) { log("failing"); panic("oops") } }`, WithSignerServiceAccount())
+
+		results, err := o.CommitBlock()
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		ok.AssertSuccess(t)
+		failing.AssertFailure(t, "oops")
+		assert.NotContains(t, ok.EmulatorLog, "failing")
+	})
+
+	t.Run("Commit empty block", func(t *testing.T) {
+		before := height(t)
+		results, err := o.CommitBlock()
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		assert.Equal(t, before+1, height(t))
+	})
+
+	t.Run("Dry runs are not supported", func(t *testing.T) {
+		result := o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0), WithDryRun())
+		assert.ErrorContains(t, result.Err, "dry runs are not supported when blocks are committed manually")
+	})
+
+	t.Run("Async transactions are not supported", func(t *testing.T) {
+		async := o.TxAsync("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0))
+		result := async.WaitSealed()
+		assert.ErrorContains(t, result.Err, "asynchronous transactions are not supported when blocks are committed manually")
+
+		// the sequence number of the proposer is not used up
+		ok := mint("first", 1.0)
+		_, err := o.CommitBlock()
+		require.NoError(t, err)
+		ok.AssertSuccess(t)
+	})
+
+	t.Run("Offline transaction in block", func(t *testing.T) {
+		tx, err := o.BuildOfflineTx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 3.0))
+		require.NoError(t, err)
+		require.NoError(t, o.SignOfflineTransaction(tx, o.ServiceAccountName()))
+
+		offline := o.SendOfflineTransaction(tx)
+		require.NoError(t, offline.Err)
+		assert.True(t, offline.Pending)
+		online := mint("second", 4.0)
+
+		results, err := o.CommitBlock()
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Same(t, offline, results[0])
+		offline.AssertSuccess(t).AssertEvent(t, "TokensMinted", map[string]interface{}{"amount": 3.0})
+		online.AssertSuccess(t).AssertEvent(t, "TokensMinted", map[string]interface{}{"amount": 4.0})
+	})
+
+	t.Run("Switch back to auto commit", func(t *testing.T) {
+		pending := mint("first", 1.0)
+		assert.ErrorContains(t, o.SetManualBlockCommit(false), "there are 1 transactions in the pending block")
+
+		_, err := o.CommitBlock()
+		require.NoError(t, err)
+		pending.AssertSuccess(t)
+
+		require.NoError(t, o.SetManualBlockCommit(false))
+		assert.False(t, o.ManualBlockCommit())
+		result := mint("first", 1.0)
+		result.AssertSuccess(t)
+		assert.False(t, result.Pending)
+
+		_, err = o.CommitBlock()
+		assert.ErrorContains(t, err, "blocks are only committed manually")
+	})
+}
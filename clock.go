@@ -0,0 +1,129 @@
+package overflow
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// the clock of the emulator, it either runs with an offset from the system clock or stands still at a time
+type overflowClock struct {
+	mutex  sync.Mutex
+	offset time.Duration
+	fixed  bool
+	time   time.Time
+}
+
+// the state of the clock that can be put back with restore
+type overflowClockState struct {
+	offset time.Duration
+	fixed  bool
+	time   time.Time
+}
+
+func (c *overflowClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.fixed {
+		return c.time
+	}
+	return time.Now().UTC().Add(c.offset)
+}
+
+func (c *overflowClock) advance(duration time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.fixed {
+		c.time = c.time.Add(duration)
+		return
+	}
+	c.offset += duration
+}
+
+func (c *overflowClock) set(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.fixed = true
+	c.time = t.UTC()
+}
+
+func (c *overflowClock) state() overflowClockState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return overflowClockState{offset: c.offset, fixed: c.fixed, time: c.time}
+}
+
+func (c *overflowClock) restore(state overflowClockState) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.offset = state.offset
+	c.fixed = state.fixed
+	c.time = state.time
+}
+
+// change the clock of the emulator, the pending block gets the new time right away
+func (o *OverflowState) changeClock(change func(clock *overflowClock)) error {
+	if o.Emulator == nil {
+		return fmt.Errorf("the block time can only be changed on the in memory emulator")
+	}
+
+	unlock := o.lockEmulatorLog()
+	defer unlock()
+	change(o.clock)
+	o.Emulator.SetClock(o.clock)
+	return nil
+}
+
+// AdvanceTime move the time of the next blocks forward by the duration, so contracts that use getCurrentBlock().timestamp can be tested without waiting, IE
+//
+//	o.Tx("start_auction", ...)
+//	o.AdvanceTime(24 * time.Hour)
+//	o.Tx("settle_auction", ...)
+func (o *OverflowState) AdvanceTime(duration time.Duration) error {
+	return o.changeClock(func(clock *overflowClock) {
+		clock.advance(duration)
+	})
+}
+
+// SetBlockTime let the next blocks have the given time, the time stands still until it is advanced or reset
+func (o *OverflowState) SetBlockTime(t time.Time) error {
+	return o.changeClock(func(clock *overflowClock) {
+		clock.set(t)
+	})
+}
+
+// ResetBlockTime let the next blocks have the time of the system clock again
+func (o *OverflowState) ResetBlockTime() error {
+	return o.changeClock(func(clock *overflowClock) {
+		clock.restore(overflowClockState{})
+	})
+}
+
+// BlockTime the time the next block will have
+func (o *OverflowState) BlockTime() time.Time {
+	return o.clock.Now()
+}
+
+// CommitEmptyBlocks commit the given number of blocks without transactions
+func (o *OverflowState) CommitEmptyBlocks(count int) error {
+	if o.Emulator == nil {
+		return fmt.Errorf("blocks can only be committed on the in memory emulator")
+	}
+
+	// the pending block is only changed while the emulator is held so we hold it before looking at it
+	unlock := o.lockEmulatorLog()
+	defer unlock()
+	if o.pendingBlock != nil && len(o.pendingBlock.transactions) > 0 {
+		return fmt.Errorf("there are %d transactions in the pending block, commit it first", len(o.pendingBlock.transactions))
+	}
+
+	for i := 0; i < count; i++ {
+		_, _, err := o.Emulator.ExecuteAndCommitBlock()
+		if err != nil {
+			return errors.Wrap(err, "could not commit empty block")
+		}
+	}
+	return nil
+}
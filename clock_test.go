@@ -0,0 +1,65 @@
+package overflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockTime(t *testing.T) {
+	ot, err := SetupTest([]OverflowOption{}, func(o *OverflowState) error { return nil })
+	require.NoError(t, err)
+	o := ot.O
+
+	timestamp := func(t *testing.T) time.Time {
+		value, err := o.Script(`access(all) fun main(): UFix64 { return getCurrentBlock().timestamp }`).GetAsInterface()
+		require.NoError(t, err)
+		return time.Unix(int64(value.(float64)), 0).UTC()
+	}
+
+	height := func(t *testing.T) uint64 {
+		block, err := o.GetLatestBlock(context.Background())
+		require.NoError(t, err)
+		return block.Height
+	}
+
+	auction := time.Date(2030, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	ot.RunWithClock(t, "Set block time", func(t *testing.T) {
+		require.NoError(t, o.SetBlockTime(auction))
+		assert.Equal(t, auction, o.BlockTime())
+
+		o.Tx(`transaction { prepare(signer: &Account) { log(getCurrentBlock().timestamp) } }`, WithSigner("first")).AssertSuccess(t)
+		assert.Equal(t, auction, timestamp(t))
+
+		require.NoError(t, o.AdvanceTime(24*time.Hour))
+		start := height(t)
+		require.NoError(t, o.CommitEmptyBlocks(3))
+		assert.Equal(t, start+3, height(t))
+		assert.Equal(t, auction.Add(24*time.Hour), timestamp(t))
+	})
+
+	t.Run("Clock is restored after test", func(t *testing.T) {
+		assert.WithinDuration(t, time.Now(), o.BlockTime(), time.Minute)
+	})
+
+	t.Run("Advance running clock", func(t *testing.T) {
+		require.NoError(t, o.AdvanceTime(time.Hour))
+		assert.WithinDuration(t, time.Now().Add(time.Hour), o.BlockTime(), time.Minute)
+		require.NoError(t, o.CommitEmptyBlocks(1))
+		assert.WithinDuration(t, time.Now().Add(time.Hour), timestamp(t), time.Minute)
+
+		require.NoError(t, o.ResetBlockTime())
+		assert.WithinDuration(t, time.Now(), o.BlockTime(), time.Minute)
+	})
+
+	t.Run("Only in memory", func(t *testing.T) {
+		network := *o
+		network.Emulator = nil
+		assert.ErrorContains(t, network.AdvanceTime(time.Hour), "the block time can only be changed on the in memory emulator")
+		assert.ErrorContains(t, network.CommitEmptyBlocks(1), "blocks can only be committed on the in memory emulator")
+	})
+}
@@ -0,0 +1,342 @@
+package overflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+// OverflowCrawlerOption a function to customize the transaction crawler builder
+type OverflowCrawlerOption func(*OverflowCrawlerBuilder)
+
+// OverflowCrawlerBuilder builder to hold info about the block range to crawl
+type OverflowCrawlerBuilder struct {
+	Ctx           context.Context
+	OverflowState *OverflowState
+
+	// only transactions where all filters return true are emitted
+	Filters []FilterFunction
+
+	FromHeight uint64
+
+	// the height to end crawling at, if EndAtCurrentHeight is set this is ignored
+	ToHeight           uint64
+	EndAtCurrentHeight bool
+
+	// the status blocks must have and how many blocks to hold back from the latest block, defaults to the settings on OverflowState
+	BlockStatus       OverflowBlockStatus
+	ConfirmationDepth uint64
+
+	// the number of blocks fetched concurrently
+	Workers int
+
+	// how many times fetching a block is retried, the backoff is doubled for every retry
+	Retries      int
+	RetryBackoff time.Duration
+}
+
+// the transactions in a block fetched by a crawler worker
+type crawledBlock struct {
+	err          error
+	transactions []OverflowTransaction
+	height       uint64
+}
+
+// CrawlTransactions walks the configured block range and calls the callback for every transaction that matches the filters.
+// Blocks are fetched by a pool of workers but transactions are always emitted in height and transaction index order.
+// Crawling stops at the first error returned from the callback.
+func (o *OverflowState) CrawlTransactions(callback func(OverflowTransaction) error, opts ...OverflowCrawlerOption) error {
+	return o.crawler(opts).crawl(callback)
+}
+
+// StreamTransactions walks the configured block range in the background and sends every transaction that matches the filters on the returned channel.
+// Both channels are closed when crawling is done, at most one error is sent.
+func (o *OverflowState) StreamTransactions(opts ...OverflowCrawlerOption) (<-chan OverflowTransaction, <-chan error) {
+	c := o.crawler(opts)
+	transactions := make(chan OverflowTransaction)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(transactions)
+
+		err := c.crawl(func(tx OverflowTransaction) error {
+			select {
+			case transactions <- tx:
+				return nil
+			case <-c.Ctx.Done():
+				return c.Ctx.Err()
+			}
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return transactions, errs
+}
+
+func (o *OverflowState) crawler(opts []OverflowCrawlerOption) *OverflowCrawlerBuilder {
+	c := &OverflowCrawlerBuilder{
+		Ctx:                context.Background(),
+		OverflowState:      o,
+		Filters:            []FilterFunction{},
+		EndAtCurrentHeight: true,
+		BlockStatus:        o.BlockStatus,
+		ConfirmationDepth:  o.ConfirmationDepth,
+		Workers:            1,
+		RetryBackoff:       500 * time.Millisecond,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *OverflowCrawlerBuilder) crawl(callback func(OverflowTransaction) error) error {
+	from, to, err := c.heightRange()
+	if err != nil {
+		return err
+	}
+	return c.crawlRange(from, to, callback)
+}
+
+func (c *OverflowCrawlerBuilder) crawlRange(from uint64, to uint64, callback func(OverflowTransaction) error) error {
+	ctx, cancel := context.WithCancel(c.Ctx)
+	defer cancel()
+
+	workers := c.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	// limit how many blocks can be fetched ahead of the block we are waiting to emit
+	window := make(chan struct{}, workers*2)
+	heights := make(chan uint64)
+	results := make(chan crawledBlock)
+
+	go func() {
+		defer close(heights)
+		for height := from; height <= to; height++ {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case heights <- height:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for height := range heights {
+				transactions, err := retryWithBackoff(ctx, c.Retries, c.RetryBackoff, func() ([]OverflowTransaction, error) {
+					return c.transactionsAtHeight(ctx, height)
+				})
+				select {
+				case results <- crawledBlock{height: height, transactions: transactions, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := map[uint64]crawledBlock{}
+	for height := from; height <= to; height++ {
+		block, ok := pending[height]
+		for !ok {
+			select {
+			case result, open := <-results:
+				if !open {
+					if err := c.Ctx.Err(); err != nil {
+						return err
+					}
+					return fmt.Errorf("crawler stopped before height %d was fetched", height)
+				}
+				pending[result.height] = result
+				block, ok = pending[height]
+			case <-c.Ctx.Done():
+				return c.Ctx.Err()
+			}
+		}
+		delete(pending, height)
+		<-window
+
+		if block.err != nil {
+			return block.err
+		}
+		for _, tx := range block.transactions {
+			err := callback(tx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *OverflowCrawlerBuilder) heightRange() (uint64, uint64, error) {
+	to, err := c.endHeight()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if c.FromHeight > to {
+		return 0, 0, fmt.Errorf("cannot crawl from height %d since it is after the end height %d", c.FromHeight, to)
+	}
+	return c.FromHeight, to, nil
+}
+
+// the height to stop crawling at
+func (c *OverflowCrawlerBuilder) endHeight() (uint64, error) {
+	latestHeight, ok, err := c.OverflowState.latestConfirmedHeight(c.Ctx, c.BlockStatus, c.ConfirmationDepth)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, fmt.Errorf("no block has %d confirmations yet", c.ConfirmationDepth)
+	}
+
+	if c.EndAtCurrentHeight || c.ToHeight > latestHeight {
+		return latestHeight, nil
+	}
+	return c.ToHeight, nil
+}
+
+// fetch all transactions in the block at the given height that match the filters
+func (c *OverflowCrawlerBuilder) transactionsAtHeight(ctx context.Context, height uint64) ([]OverflowTransaction, error) {
+	o := c.OverflowState
+	block, err := o.GetBlockAtHeight(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	txs, txResults, err := o.GetTransactionsByBlockId(ctx, block.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not fetch transactions for block at height %d", height)
+	}
+
+	result := []OverflowTransaction{}
+	for i, tx := range txs {
+		if i >= len(txResults) {
+			break
+		}
+		ot, err := o.CreateOverflowTransaction(block.ID.String(), *txResults[i], *tx, i)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not create transaction %s at height %d", tx.ID(), height)
+		}
+		ot.BlockHeight = height
+
+		if !slices.ContainsFunc(c.Filters, func(filter FilterFunction) bool { return !filter(*ot) }) {
+			result = append(result, *ot)
+		}
+	}
+	return result, nil
+}
+
+// start crawling at the given height
+func WithCrawlFromHeight(height uint64) OverflowCrawlerOption {
+	return func(c *OverflowCrawlerBuilder) {
+		c.FromHeight = height
+	}
+}
+
+// stop crawling at the given height, if it is after the latest block the latest block is used
+func WithCrawlToHeight(height uint64) OverflowCrawlerOption {
+	return func(c *OverflowCrawlerBuilder) {
+		c.ToHeight = height
+		c.EndAtCurrentHeight = false
+	}
+}
+
+// only emit transactions where all the given filters return true, can be called multiple times
+func WithTransactionFilter(filters ...FilterFunction) OverflowCrawlerOption {
+	return func(c *OverflowCrawlerBuilder) {
+		c.Filters = append(c.Filters, filters...)
+	}
+}
+
+// only crawl blocks with the given status
+func WithCrawlBlockStatus(status OverflowBlockStatus) OverflowCrawlerOption {
+	return func(c *OverflowCrawlerBuilder) {
+		c.BlockStatus = status
+	}
+}
+
+// hold back this many blocks from the latest block so that only confirmed blocks are crawled
+func WithCrawlConfirmationDepth(depth uint64) OverflowCrawlerOption {
+	return func(c *OverflowCrawlerBuilder) {
+		c.ConfirmationDepth = depth
+	}
+}
+
+// fetch this many blocks concurrently, transactions are still emitted in order
+func WithCrawlWorkers(workers int) OverflowCrawlerOption {
+	return func(c *OverflowCrawlerBuilder) {
+		c.Workers = workers
+	}
+}
+
+// retry fetching a block this many times, waiting backoff before the first retry and doubling it for every retry after that
+func WithCrawlRetries(retries int, backoff time.Duration) OverflowCrawlerOption {
+	return func(c *OverflowCrawlerBuilder) {
+		c.Retries = retries
+		c.RetryBackoff = backoff
+	}
+}
+
+// set the context used when crawling
+func WithCrawlContext(ctx context.Context) OverflowCrawlerOption {
+	return func(c *OverflowCrawlerBuilder) {
+		c.Ctx = ctx
+	}
+}
+
+// a filter that only keeps transactions where the given address is a stakeholder
+func StakeholderFilter(address string) FilterFunction {
+	return func(tx OverflowTransaction) bool {
+		_, ok := tx.Stakeholders[address]
+		return ok
+	}
+}
+
+// a filter that only keeps transactions that emitted an event with the given suffix
+func EventNameFilter(suffix string) FilterFunction {
+	return func(tx OverflowTransaction) bool {
+		return slices.ContainsFunc(tx.Events, func(ev OverflowEvent) bool {
+			return strings.HasSuffix(ev.Name, suffix)
+		})
+	}
+}
+
+// run fn and retry it the given number of times if it fails, doubling the backoff for every retry
+func retryWithBackoff[T any](ctx context.Context, retries int, backoff time.Duration, fn func() (T, error)) (T, error) {
+	result, err := fn()
+	for attempt := 0; err != nil && attempt < retries; attempt++ {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		case <-time.After(backoff << attempt):
+		}
+		result, err = fn()
+	}
+	return result, err
+}
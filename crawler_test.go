@@ -0,0 +1,134 @@
+package overflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawlTransactions(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+	o.Tx("arguments", WithSigner("second"), WithArg("test", "foo")).AssertSuccess(t)
+
+	t.Run("Crawl all transactions in range", func(t *testing.T) {
+		transactions := []OverflowTransaction{}
+		err := o.CrawlTransactions(func(tx OverflowTransaction) error {
+			transactions = append(transactions, tx)
+			return nil
+		}, WithCrawlFromHeight(6))
+		require.NoError(t, err)
+		require.NotEmpty(t, transactions)
+
+		tx := transactions[0]
+		assert.Equal(t, uint64(6), tx.BlockHeight)
+		assert.Equal(t, "0xf8d6e0586b0a20c7", tx.Payer)
+		assert.Equal(t, "recipient", tx.Arguments[0].Key)
+		assert.Equal(t, "0x179b6b1cb6755e31", tx.Arguments[0].Value)
+	})
+
+	t.Run("Crawl with stakeholder filter", func(t *testing.T) {
+		transactions := []OverflowTransaction{}
+		err := o.CrawlTransactions(func(tx OverflowTransaction) error {
+			transactions = append(transactions, tx)
+			return nil
+		}, WithCrawlFromHeight(6), WithTransactionFilter(StakeholderFilter("0xf3fcd2c1a78f5eee")))
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, uint64(7), transactions[0].BlockHeight)
+		assert.Contains(t, transactions[0].Stakeholders["0xf3fcd2c1a78f5eee"], "authorizer")
+	})
+
+	t.Run("Stream transactions until height", func(t *testing.T) {
+		transactions, errs := o.StreamTransactions(WithCrawlFromHeight(6), WithCrawlToHeight(6), WithTransactionFilter(EventNameFilter("FlowToken.TokensMinted")))
+
+		heights := []uint64{}
+		for tx := range transactions {
+			heights = append(heights, tx.BlockHeight)
+		}
+		require.NoError(t, <-errs)
+		assert.Equal(t, []uint64{6}, heights)
+	})
+
+	t.Run("Stream transactions stops on cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		transactions, errs := o.StreamTransactions(WithCrawlContext(ctx))
+		for range transactions {
+		}
+		assert.ErrorIs(t, <-errs, context.Canceled)
+	})
+
+	t.Run("Crawl with workers emits in order", func(t *testing.T) {
+		sequential := []string{}
+		err := o.CrawlTransactions(func(tx OverflowTransaction) error {
+			sequential = append(sequential, fmt.Sprintf("%d-%d", tx.BlockHeight, tx.TransactionIndex))
+			return nil
+		}, WithCrawlFromHeight(1))
+		require.NoError(t, err)
+
+		concurrent := []string{}
+		err = o.CrawlTransactions(func(tx OverflowTransaction) error {
+			concurrent = append(concurrent, fmt.Sprintf("%d-%d", tx.BlockHeight, tx.TransactionIndex))
+			return nil
+		}, WithCrawlFromHeight(1), WithCrawlWorkers(4), WithCrawlRetries(2, time.Millisecond))
+		require.NoError(t, err)
+		assert.NotEmpty(t, concurrent)
+		assert.Equal(t, sequential, concurrent)
+	})
+
+	t.Run("Crawl with workers stops at callback error", func(t *testing.T) {
+		calls := 0
+		err := o.CrawlTransactions(func(tx OverflowTransaction) error {
+			calls++
+			return fmt.Errorf("stop")
+		}, WithCrawlFromHeight(1), WithCrawlWorkers(4))
+		assert.ErrorContains(t, err, "stop")
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Crawl from height after end should fail", func(t *testing.T) {
+		err := o.CrawlTransactions(func(tx OverflowTransaction) error { return nil }, WithCrawlFromHeight(100))
+		assert.ErrorContains(t, err, "cannot crawl from height 100")
+	})
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	t.Run("Retries until success", func(t *testing.T) {
+		attempts := 0
+		result, err := retryWithBackoff(context.Background(), 3, time.Millisecond, func() (int, error) {
+			attempts++
+			if attempts < 3 {
+				return 0, fmt.Errorf("attempt %d failed", attempts)
+			}
+			return attempts, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result)
+	})
+
+	t.Run("Returns last error", func(t *testing.T) {
+		attempts := 0
+		_, err := retryWithBackoff(context.Background(), 2, time.Millisecond, func() (int, error) {
+			attempts++
+			return 0, fmt.Errorf("attempt %d failed", attempts)
+		})
+		assert.ErrorContains(t, err, "attempt 3 failed")
+	})
+
+	t.Run("Stops on cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := retryWithBackoff(ctx, 2, time.Hour, func() (int, error) {
+			return 0, fmt.Errorf("failed")
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,241 @@
+package overflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime"
+	"github.com/onflow/flow-emulator/adapters"
+	"github.com/onflow/flow-emulator/emulator"
+	"github.com/onflow/flow-emulator/types"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flowkit/v2/gateway"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// OverflowEmulatorGateway the flowkit gateway to the in memory emulator.
+// Overflow creates the emulator itself so it can control how blocks are committed, snapshots and the clock
+type OverflowEmulatorGateway struct {
+	emulator *emulator.Blockchain
+	adapter  *adapters.SDKAdapter
+}
+
+var _ gateway.Gateway = (*OverflowEmulatorGateway)(nil)
+
+// report data the emulator does not have as NotFound like an access node does, so the archive fallback can see it
+func emulatorError(err error) error {
+	var notFound types.NotFoundError
+	if errors.As(err, &notFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return gateway.UnwrapStatusError(err)
+}
+
+// create the emulator with the service key and a gateway to it that mines a block for every transaction
+func newEmulatorGateway(key *gateway.EmulatorKey, logger *zerolog.Logger, opts ...emulator.Option) (*OverflowEmulatorGateway, error) {
+	allOpts := []emulator.Option{emulator.WithServicePublicKey(key.PublicKey, key.SigAlgo, key.HashAlgo)}
+	allOpts = append(allOpts, opts...)
+
+	blockchain, err := emulator.New(allOpts...)
+	if err != nil {
+		return nil, err
+	}
+	blockchain.EnableAutoMine()
+
+	return &OverflowEmulatorGateway{
+		emulator: blockchain,
+		adapter:  adapters.NewSDKAdapter(logger, blockchain),
+	}, nil
+}
+
+func (g *OverflowEmulatorGateway) GetAccount(ctx context.Context, address flow.Address) (*flow.Account, error) {
+	account, err := g.adapter.GetAccount(ctx, address)
+	if err != nil {
+		return nil, emulatorError(err)
+	}
+	return account, nil
+}
+
+func (g *OverflowEmulatorGateway) SendSignedTransaction(ctx context.Context, tx *flow.Transaction) (*flow.Transaction, error) {
+	err := g.adapter.SendTransaction(ctx, *tx)
+	if err != nil {
+		return nil, emulatorError(err)
+	}
+	return tx, nil
+}
+
+func (g *OverflowEmulatorGateway) GetTransaction(ctx context.Context, id flow.Identifier) (*flow.Transaction, error) {
+	tx, err := g.adapter.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, emulatorError(err)
+	}
+	return tx, nil
+}
+
+func (g *OverflowEmulatorGateway) GetTransactionResultsByBlockID(ctx context.Context, id flow.Identifier) ([]*flow.TransactionResult, error) {
+	results, err := g.adapter.GetTransactionResultsByBlockID(ctx, id)
+	if err != nil {
+		return nil, emulatorError(err)
+	}
+	return results, nil
+}
+
+func (g *OverflowEmulatorGateway) GetTransactionResult(ctx context.Context, id flow.Identifier, _ bool) (*flow.TransactionResult, error) {
+	result, err := g.adapter.GetTransactionResult(ctx, id)
+	if err != nil {
+		return nil, emulatorError(err)
+	}
+	return result, nil
+}
+
+func (g *OverflowEmulatorGateway) GetTransactionsByBlockID(ctx context.Context, id flow.Identifier) ([]*flow.Transaction, error) {
+	txs, err := g.adapter.GetTransactionsByBlockID(ctx, id)
+	if err != nil {
+		return nil, emulatorError(err)
+	}
+	return txs, nil
+}
+
+func (g *OverflowEmulatorGateway) GetSystemTransaction(_ context.Context, _ flow.Identifier) (*flow.Transaction, error) {
+	return nil, fmt.Errorf("GetSystemTransaction is not implemented")
+}
+
+func (g *OverflowEmulatorGateway) GetSystemTransactionResult(_ context.Context, _ flow.Identifier) (*flow.TransactionResult, error) {
+	return nil, fmt.Errorf("GetSystemTransactionResult is not implemented")
+}
+
+func (g *OverflowEmulatorGateway) GetSystemTransactionWithID(_ context.Context, _ flow.Identifier, _ flow.Identifier) (*flow.Transaction, error) {
+	return nil, fmt.Errorf("GetSystemTransactionWithID is not implemented")
+}
+
+func (g *OverflowEmulatorGateway) GetSystemTransactionResultWithID(_ context.Context, _ flow.Identifier, _ flow.Identifier) (*flow.TransactionResult, error) {
+	return nil, fmt.Errorf("GetSystemTransactionResultWithID is not implemented")
+}
+
+// run a script at the block with the id, at the height or at the latest block if neither is set
+func (g *OverflowEmulatorGateway) executeScript(ctx context.Context, script []byte, arguments []cadence.Value, id flow.Identifier, height uint64) (cadence.Value, error) {
+	args := make([][]byte, len(arguments))
+	for i, argument := range arguments {
+		arg, err := jsoncdc.Encode(argument)
+		if err != nil {
+			return nil, fmt.Errorf("convert: %w", err)
+		}
+		args[i] = arg
+	}
+
+	var result []byte
+	var err error
+	if id != flow.EmptyID {
+		result, err = g.adapter.ExecuteScriptAtBlockID(ctx, id, script, args)
+	} else if height > 0 {
+		result, err = g.adapter.ExecuteScriptAtBlockHeight(ctx, height, script, args)
+	} else {
+		result, err = g.adapter.ExecuteScriptAtLatestBlock(ctx, script, args)
+	}
+	if err != nil {
+		return nil, emulatorError(err)
+	}
+
+	value, err := jsoncdc.Decode(nil, result)
+	if err != nil {
+		return nil, fmt.Errorf("convert: %w", err)
+	}
+	return value, nil
+}
+
+func (g *OverflowEmulatorGateway) ExecuteScript(ctx context.Context, script []byte, arguments []cadence.Value) (cadence.Value, error) {
+	return g.executeScript(ctx, script, arguments, flow.EmptyID, 0)
+}
+
+func (g *OverflowEmulatorGateway) ExecuteScriptAtHeight(ctx context.Context, script []byte, arguments []cadence.Value, height uint64) (cadence.Value, error) {
+	return g.executeScript(ctx, script, arguments, flow.EmptyID, height)
+}
+
+func (g *OverflowEmulatorGateway) ExecuteScriptAtID(ctx context.Context, script []byte, arguments []cadence.Value, id flow.Identifier) (cadence.Value, error) {
+	return g.executeScript(ctx, script, arguments, id, 0)
+}
+
+func (g *OverflowEmulatorGateway) GetLatestBlock(ctx context.Context) (*flow.Block, error) {
+	block, _, err := g.adapter.GetLatestBlock(ctx, true)
+	if err != nil {
+		return nil, emulatorError(err)
+	}
+	return block, nil
+}
+
+func (g *OverflowEmulatorGateway) GetBlockByHeight(ctx context.Context, height uint64) (*flow.Block, error) {
+	block, _, err := g.adapter.GetBlockByHeight(ctx, height)
+	if err != nil {
+		// the adapter reports a missing block as an internal error, ask the emulator so we can tell it apart
+		if _, lookupErr := g.emulator.GetBlockByHeight(height); lookupErr != nil {
+			return nil, emulatorError(lookupErr)
+		}
+		return nil, emulatorError(err)
+	}
+	return block, nil
+}
+
+func (g *OverflowEmulatorGateway) GetBlockByID(ctx context.Context, id flow.Identifier) (*flow.Block, error) {
+	block, _, err := g.adapter.GetBlockByID(ctx, id)
+	if err != nil {
+		return nil, emulatorError(err)
+	}
+	return block, nil
+}
+
+func (g *OverflowEmulatorGateway) GetEvents(ctx context.Context, eventType string, startHeight uint64, endHeight uint64) ([]flow.BlockEvents, error) {
+	events := []flow.BlockEvents{}
+	for height := startHeight; height <= endHeight; height++ {
+		blockEvents, err := g.adapter.GetEventsForHeightRange(ctx, eventType, height, height)
+		if err != nil {
+			return nil, emulatorError(err)
+		}
+		events = append(events, *blockEvents[0])
+	}
+	return events, nil
+}
+
+func (g *OverflowEmulatorGateway) GetCollection(ctx context.Context, id flow.Identifier) (*flow.Collection, error) {
+	collection, err := g.adapter.GetCollectionByID(ctx, id)
+	if err != nil {
+		return nil, emulatorError(err)
+	}
+	return collection, nil
+}
+
+func (g *OverflowEmulatorGateway) GetLatestProtocolStateSnapshot(ctx context.Context) ([]byte, error) {
+	snapshot, err := g.adapter.GetLatestProtocolStateSnapshot(ctx)
+	if err != nil {
+		return nil, emulatorError(err)
+	}
+	return snapshot, nil
+}
+
+func (g *OverflowEmulatorGateway) Ping() error {
+	err := g.adapter.Ping(context.Background())
+	if err != nil {
+		return emulatorError(err)
+	}
+	return nil
+}
+
+func (g *OverflowEmulatorGateway) WaitServer(_ context.Context) error {
+	return nil
+}
+
+func (g *OverflowEmulatorGateway) SecureConnection() bool {
+	return false
+}
+
+func (g *OverflowEmulatorGateway) CoverageReport() *runtime.CoverageReport {
+	return g.emulator.CoverageReport()
+}
+
+func (g *OverflowEmulatorGateway) RollbackToBlockHeight(height uint64) error {
+	return g.emulator.RollbackToBlockHeight(height)
+}
@@ -43,13 +43,14 @@ func (me OverflowEvents) GetStakeholders(stakeholders map[string][]string) map[s
 }
 
 type OverflowEvent struct {
-	Fields        map[string]interface{} `json:"fields"`
-	Addresses     map[string][]string    `json:"addresses"`
-	Id            string                 `json:"id"`
-	TransactionId string                 `json:"transactionID"`
-	Name          string                 `json:"name"`
-	RawEvent      cadence.Event          `json:"rawEvent"`
-	EventIndex    uint32                 `json:"eventIndex"`
+	Fields           map[string]interface{} `json:"fields"`
+	Addresses        map[string][]string    `json:"addresses"`
+	Id               string                 `json:"id"`
+	TransactionId    string                 `json:"transactionID"`
+	Name             string                 `json:"name"`
+	RawEvent         cadence.Event          `json:"rawEvent"`
+	EventIndex       uint32                 `json:"eventIndex"`
+	TransactionIndex int                    `json:"transactionIndex"`
 }
 
 type FeeBalance struct {
@@ -127,47 +128,48 @@ func (o *OverflowState) ParseEventsWithIdPrefix(events []flow.Event, idPrefix st
 	overflowEvents := OverflowEvents{}
 	fee := OverflowEvent{}
 	for i, event := range events {
-		finalFields := map[string]interface{}{}
-		addresses := map[string][]string{}
-
-		for name, field := range cadence.FieldsMappedByName(event.Value) {
-
-			adr := underflow.ExtractAddresses(field)
-			if len(adr) > 0 {
-				addresses[name] = adr
-			}
-			value := underflow.CadenceValueToInterfaceWithOption(field, o.UnderflowOptions)
-			if value != nil {
-				finalFields[name] = value
-			}
-		}
-
-		events, ok := overflowEvents[event.Type]
-		if !ok {
-			events = []OverflowEvent{}
-		}
-		events = append(events, OverflowEvent{
-			Id:            fmt.Sprintf("%s%s-%d", idPrefix, event.TransactionID.Hex(), i),
-			Fields:        finalFields,
-			Name:          event.Type,
-			TransactionId: event.TransactionID.String(),
-			EventIndex:    uint32(event.EventIndex),
-			Addresses:     addresses,
-			RawEvent:      event.Value,
-		})
-		overflowEvents[event.Type] = events
+		parsed := o.parseEvent(event, fmt.Sprintf("%s%s-%d", idPrefix, event.TransactionID.Hex(), i))
+		overflowEvents[event.Type] = append(overflowEvents[event.Type], parsed)
 		if strings.HasSuffix(event.Type, "FlowFees.FeesDeducted") {
 			fee = OverflowEvent{
-				Fields:        finalFields,
+				Fields:        parsed.Fields,
 				Name:          event.Type,
 				TransactionId: event.TransactionID.String(),
 			}
 		}
-
 	}
 	return overflowEvents, fee
 }
 
+// parse a single event with the given id
+func (o *OverflowState) parseEvent(event flow.Event, id string) OverflowEvent {
+	finalFields := map[string]interface{}{}
+	addresses := map[string][]string{}
+
+	for name, field := range cadence.FieldsMappedByName(event.Value) {
+
+		adr := underflow.ExtractAddresses(field)
+		if len(adr) > 0 {
+			addresses[name] = adr
+		}
+		value := underflow.CadenceValueToInterfaceWithOption(field, o.UnderflowOptions)
+		if value != nil {
+			finalFields[name] = value
+		}
+	}
+
+	return OverflowEvent{
+		Id:               id,
+		Fields:           finalFields,
+		Name:             event.Type,
+		TransactionId:    event.TransactionID.String(),
+		EventIndex:       uint32(event.EventIndex),
+		TransactionIndex: event.TransactionIndex,
+		Addresses:        addresses,
+		RawEvent:         event.Value,
+	}
+}
+
 func (overflowEvents OverflowEvents) FilterTempWithdrawDeposit() OverflowEvents {
 	filteredEvents := overflowEvents
 	for name, events := range overflowEvents {
@@ -398,7 +400,8 @@ func (overflowEvents OverflowEvents) FilterEvents(ignoreFields OverflowEventFilt
 
 		eventList := []OverflowEvent{}
 		for _, ev := range events {
-			event := OverflowEvent{Fields: map[string]interface{}{}}
+			event := ev
+			event.Fields = map[string]interface{}{}
 			for key, value := range ev.Fields {
 				valid := true
 				for _, ig := range ignoreFieldNames {
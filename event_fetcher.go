@@ -0,0 +1,368 @@
+package overflow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flowkit/v2"
+	"github.com/pkg/errors"
+)
+
+// OverflowEventFetcherOption a function to customize the event fetcher builder
+type OverflowEventFetcherOption func(*OverflowEventFetcherBuilder)
+
+// OverflowEventFetcherBuilder builder to hold info about the events to fetch from a range of blocks, the progress can be stored in a file
+type OverflowEventFetcherBuilder struct {
+	Ctx           context.Context
+	OverflowState *OverflowState
+
+	// the events to fetch and the fields to ignore for each of them
+	EventsAndIgnoreFields OverflowEventFilter
+
+	// the height to start fetching from, if negative it is relative to the end height
+	FromIndex int64
+
+	// the height to end fetching at, if EndAtCurrentHeight is set this is ignored
+	EndIndex           uint64
+	EndAtCurrentHeight bool
+
+	// if set the height to start from is read from this file and the next height to fetch is written back to it
+	ProgressFile string
+
+	NumberOfWorkers int
+	EventBatchSize  uint64
+
+	// only used when subscribing, start after the latest block instead of at FromIndex
+	OnlyNewBlocks bool
+
+	// only used when subscribing, how long to wait before polling for new blocks
+	PollInterval time.Duration
+
+	// the status blocks must have and how many blocks to hold back from the latest block, defaults to the settings on OverflowState
+	BlockStatus       OverflowBlockStatus
+	ConfirmationDepth uint64
+
+	// fetched events are written to these sinks in batches and flushed before the progress file is updated
+	Sinks         []EventSink
+	SinkBatchSize int
+}
+
+// OverflowBlockEvents the events emitted in a single block
+type OverflowBlockEvents struct {
+	Events OverflowEvents
+	// the same events in the order they were emitted
+	EventList      OverflowEventList
+	BlockTimestamp time.Time
+	BlockId        string
+	Height         uint64
+}
+
+// OverflowEventFetcherResult the result of fetching events
+type OverflowEventFetcherResult struct {
+	Error error
+	// all events in the range grouped by event type, in block order
+	Events OverflowEvents
+	// the events for each block in the range that emitted any of the requested events
+	Blocks []OverflowBlockEvents
+	From   uint64
+	To     uint64
+}
+
+// FetchEvents fetch the events configured with the given options, see FetchEventsWithResult if you need to know what range was fetched
+func (o *OverflowState) FetchEvents(opts ...OverflowEventFetcherOption) (OverflowEvents, error) {
+	result := o.FetchEventsWithResult(opts...)
+	return result.Events, result.Error
+}
+
+// FetchEventsWithResult fetch the events configured with the given options and return the range that was fetched
+func (o *OverflowState) FetchEventsWithResult(opts ...OverflowEventFetcherOption) OverflowEventFetcherResult {
+	return o.eventFetcher(opts).run()
+}
+
+func (o *OverflowState) eventFetcher(opts []OverflowEventFetcherOption) *OverflowEventFetcherBuilder {
+	e := &OverflowEventFetcherBuilder{
+		Ctx:                   context.Background(),
+		OverflowState:         o,
+		EventsAndIgnoreFields: OverflowEventFilter{},
+		EndAtCurrentHeight:    true,
+		FromIndex:             -10,
+		NumberOfWorkers:       1,
+		EventBatchSize:        250,
+		PollInterval:          time.Second,
+		Sinks:                 []EventSink{},
+		SinkBatchSize:         100,
+		BlockStatus:           o.BlockStatus,
+		ConfirmationDepth:     o.ConfirmationDepth,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func (e *OverflowEventFetcherBuilder) run() OverflowEventFetcherResult {
+	res := e.fetch()
+	if res.Error != nil {
+		return res
+	}
+	res.Error = e.saveProgress(res)
+	return res
+}
+
+// save that everything up to the end of the result has been handled, so the next run with the progress file continues after it
+func (e *OverflowEventFetcherBuilder) saveProgress(res OverflowEventFetcherResult) error {
+	if e.ProgressFile == "" || res.From > res.To {
+		return nil
+	}
+	return writeProgressToFile(e.ProgressFile, int64(res.To+1))
+}
+
+// fetch the events without saving the progress
+func (e *OverflowEventFetcherBuilder) fetch() OverflowEventFetcherResult {
+	res := OverflowEventFetcherResult{Events: OverflowEvents{}, Blocks: []OverflowBlockEvents{}}
+	if len(e.EventsAndIgnoreFields) == 0 {
+		res.Error = fmt.Errorf("specify at least one event to fetch using WithEvent or WithEvents")
+		return res
+	}
+
+	latestHeight, ok, err := e.OverflowState.latestConfirmedHeight(e.Ctx, e.BlockStatus, e.ConfirmationDepth)
+	if err != nil {
+		res.Error = err
+		return res
+	}
+	if !ok {
+		res.Error = fmt.Errorf("no block has %d confirmations yet", e.ConfirmationDepth)
+		return res
+	}
+
+	endIndex := e.EndIndex
+	if e.EndAtCurrentHeight || endIndex > latestHeight {
+		endIndex = latestHeight
+	}
+
+	fromIndex := e.FromIndex
+	if fromIndex < 0 {
+		fromIndex = int64(endIndex) + fromIndex
+		if fromIndex < 0 {
+			fromIndex = 0
+		}
+	}
+
+	if e.ProgressFile != "" {
+		present, err := exists(e.ProgressFile)
+		if err != nil {
+			res.Error = err
+			return res
+		}
+
+		if present {
+			fromIndex, err = readProgressFromFile(e.ProgressFile)
+			if err != nil {
+				res.Error = errors.Wrapf(err, "could not read progress file %s", e.ProgressFile)
+				return res
+			}
+		} else {
+			err := writeProgressToFile(e.ProgressFile, fromIndex)
+			if err != nil {
+				res.Error = err
+				return res
+			}
+		}
+	}
+
+	res.From = uint64(fromIndex)
+	res.To = endIndex
+
+	// we have already fetched everything up to the end
+	if res.From > res.To {
+		return res
+	}
+
+	eventNames := []string{}
+	for name := range e.EventsAndIgnoreFields {
+		eventNames = append(eventNames, name)
+	}
+	sort.Strings(eventNames)
+
+	blockEvents, err := withArchiveFallback(e.OverflowState, func(fk *flowkit.Flowkit) ([]flow.BlockEvents, error) {
+		return fk.GetEvents(e.Ctx, eventNames, res.From, res.To, &flowkit.EventWorker{
+			Count:           e.NumberOfWorkers,
+			BlocksPerWorker: e.EventBatchSize,
+		})
+	})
+	if err != nil {
+		res.Error = errors.Wrapf(err, "could not fetch events from height %d to %d", res.From, res.To)
+		return res
+	}
+
+	res.Blocks = e.OverflowState.parseBlockEvents(blockEvents, e.EventsAndIgnoreFields)
+	for _, block := range res.Blocks {
+		for name, events := range block.Events {
+			res.Events[name] = append(res.Events[name], events...)
+		}
+	}
+
+	err = e.writeToSinks(res.Blocks)
+	if err != nil {
+		res.Error = errors.Wrapf(err, "could not write events from height %d to %d", res.From, res.To)
+		return res
+	}
+	return res
+}
+
+// group the block events from flowkit per block, one block event is returned for each event type, and parse them into overflow events
+func (o *OverflowState) parseBlockEvents(blockEvents []flow.BlockEvents, filter OverflowEventFilter) []OverflowBlockEvents {
+	grouped := map[uint64][]flow.Event{}
+	blocks := map[uint64]OverflowBlockEvents{}
+	for _, be := range blockEvents {
+		if len(be.Events) == 0 {
+			continue
+		}
+		grouped[be.Height] = append(grouped[be.Height], be.Events...)
+		blocks[be.Height] = OverflowBlockEvents{
+			Height:         be.Height,
+			BlockId:        be.BlockID.String(),
+			BlockTimestamp: be.BlockTimestamp,
+		}
+	}
+
+	heights := []uint64{}
+	for height := range grouped {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+
+	result := []OverflowBlockEvents{}
+	for _, height := range heights {
+		events := grouped[height]
+		sort.SliceStable(events, func(i, j int) bool {
+			if events[i].TransactionIndex != events[j].TransactionIndex {
+				return events[i].TransactionIndex < events[j].TransactionIndex
+			}
+			return events[i].EventIndex < events[j].EventIndex
+		})
+
+		block := blocks[height]
+		block.Events = OverflowEvents{}
+		for _, ev := range events {
+			// the id has the position of the event in its transaction, the same id the event has in the result of the transaction
+			event := o.parseEvent(ev, fmt.Sprintf("%s-%d", ev.TransactionID.Hex(), ev.EventIndex))
+			for name, filtered := range (OverflowEvents{ev.Type: {event}}).FilterEvents(filter) {
+				block.Events[name] = append(block.Events[name], filtered...)
+				block.EventList = append(block.EventList, filtered...)
+			}
+		}
+		if len(block.Events) == 0 {
+			continue
+		}
+		result = append(result, block)
+	}
+	return result
+}
+
+// fetch events with the given name, can be called multiple times
+func WithEvent(eventName string) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.EventsAndIgnoreFields[eventName] = []string{}
+	}
+}
+
+// fetch events with the given name ignoring the given fields
+func WithEventIgnoringField(eventName string, ignoreFields []string) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.EventsAndIgnoreFields[eventName] = ignoreFields
+	}
+}
+
+// fetch all the events in the given filter ignoring the fields specified for each
+func WithEvents(events OverflowEventFilter) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		for name, fields := range events {
+			e.EventsAndIgnoreFields[name] = fields
+		}
+	}
+}
+
+// start fetching events at the given height
+func WithFromHeight(height uint64) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.FromIndex = int64(height)
+		e.OnlyNewBlocks = false
+	}
+}
+
+// fetch events from the given number of blocks before the end height
+func WithLastBlocks(number uint64) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.FromIndex = -int64(number)
+		e.OnlyNewBlocks = false
+	}
+}
+
+// stop fetching events at the given height, if it is after the latest block the latest block is used
+func WithUntilHeight(height uint64) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.EndIndex = height
+		e.EndAtCurrentHeight = false
+	}
+}
+
+// fetch events until the latest block, this is the default
+func WithUntilCurrentBlock() OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.EndAtCurrentHeight = true
+	}
+}
+
+// read the height to start from in the given file and write the next height to fetch to it when done
+func WithProgressFile(fileName string) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.ProgressFile = fileName
+	}
+}
+
+// the number of workers to fetch events with concurrently
+func WithWorkers(workers int) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.NumberOfWorkers = workers
+	}
+}
+
+// the number of blocks each worker fetches events for in a single request
+func WithBatchSize(size uint64) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.EventBatchSize = size
+	}
+}
+
+// only fetch events from blocks with the given status
+func WithFetchBlockStatus(status OverflowBlockStatus) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.BlockStatus = status
+	}
+}
+
+// hold back this many blocks from the latest block so that only events from confirmed blocks are fetched
+func WithFetchConfirmationDepth(depth uint64) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.ConfirmationDepth = depth
+	}
+}
+
+// how long to wait between polling for new blocks when subscribing to events
+func WithPollInterval(interval time.Duration) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.PollInterval = interval
+	}
+}
+
+// set the context used when fetching events
+func WithFetchContext(ctx context.Context) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.Ctx = ctx
+	}
+}
@@ -0,0 +1,94 @@
+package overflow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchEvents(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	minted := o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0))
+	minted.AssertSuccess(t)
+
+	mintedEvent := "A.0ae53cb6e3f42a79.FlowToken.TokensMinted"
+	depositedEvent := "A.0ae53cb6e3f42a79.FlowToken.TokensDeposited"
+
+	t.Run("Fetch events from height", func(t *testing.T) {
+		events, err := o.FetchEvents(WithEvent(mintedEvent), WithFromHeight(6))
+		require.NoError(t, err)
+		require.Len(t, events[mintedEvent], 1)
+
+		event := events[mintedEvent][0]
+		assert.Equal(t, 1.0, event.Fields["amount"])
+		assert.NotEmpty(t, event.TransactionId)
+		assert.True(t, strings.HasPrefix(event.Id, strings.TrimPrefix(event.TransactionId, "0x")))
+		assert.Equal(t, minted.Events[mintedEvent][0].Id, event.Id)
+	})
+
+	t.Run("Fetch events with result and ignored fields", func(t *testing.T) {
+		result := o.FetchEventsWithResult(WithEventIgnoringField(depositedEvent, []string{"amount"}), WithLastBlocks(3), WithWorkers(2), WithBatchSize(1))
+		require.NoError(t, result.Error)
+		assert.Equal(t, uint64(3), result.From)
+		assert.Equal(t, uint64(6), result.To)
+		require.Len(t, result.Blocks, 4)
+		assert.Equal(t, uint64(6), result.Blocks[3].Height)
+
+		require.Len(t, result.Blocks[3].EventList, len(result.Blocks[3].Events[depositedEvent]))
+
+		event := result.Blocks[3].EventList[0]
+		assert.NotContains(t, event.Fields, "amount")
+		assert.Equal(t, "0x179b6b1cb6755e31", event.Fields["to"])
+		assert.Equal(t, minted.Events[depositedEvent][0].Id, event.Id)
+	})
+
+	t.Run("Fetch events until height", func(t *testing.T) {
+		events, err := o.FetchEvents(WithEvent(mintedEvent), WithFromHeight(5), WithUntilHeight(5))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("Fetch events resumes from progress file", func(t *testing.T) {
+		progressFile := filepath.Join(t.TempDir(), "progress")
+
+		events, err := o.FetchEvents(WithEvent(mintedEvent), WithFromHeight(6), WithProgressFile(progressFile))
+		require.NoError(t, err)
+		assert.Len(t, events[mintedEvent], 1)
+
+		progress, err := os.ReadFile(progressFile)
+		require.NoError(t, err)
+		assert.Equal(t, "7", string(progress))
+
+		o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "second"), WithArg("amount", 2.0)).AssertSuccess(t)
+
+		events, err = o.FetchEvents(WithEvent(mintedEvent), WithFromHeight(0), WithProgressFile(progressFile))
+		require.NoError(t, err)
+		require.Len(t, events[mintedEvent], 1)
+		assert.Equal(t, 2.0, events[mintedEvent][0].Fields["amount"])
+
+		events, err = o.FetchEvents(WithEvent(mintedEvent), WithProgressFile(progressFile))
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("Fetch events with invalid progress file", func(t *testing.T) {
+		progressFile := filepath.Join(t.TempDir(), "progress")
+		require.NoError(t, os.WriteFile(progressFile, []byte("foo"), 0644))
+
+		_, err := o.FetchEvents(WithEvent(mintedEvent), WithProgressFile(progressFile))
+		assert.ErrorContains(t, err, fmt.Sprintf("could not read progress file %s", progressFile))
+	})
+
+	t.Run("Fetch events without any events", func(t *testing.T) {
+		_, err := o.FetchEvents(WithFromHeight(0))
+		assert.ErrorContains(t, err, "specify at least one event to fetch")
+	})
+}
@@ -0,0 +1,338 @@
+package overflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sanity-io/litter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonpointer"
+)
+
+// OverflowEventOperator how the value a pointer points to is compared to the value of a condition
+type OverflowEventOperator string
+
+const (
+	EventOperatorEquals             OverflowEventOperator = "=="
+	EventOperatorNotEquals          OverflowEventOperator = "!="
+	EventOperatorGreaterThan        OverflowEventOperator = ">"
+	EventOperatorGreaterThanOrEqual OverflowEventOperator = ">="
+	EventOperatorLessThan           OverflowEventOperator = "<"
+	EventOperatorLessThanOrEqual    OverflowEventOperator = "<="
+	// strings contain the value as a substring, arrays contain it as an element and dictionaries as a key
+	EventOperatorContains OverflowEventOperator = "contains"
+	// the value is a regular expression that must match
+	EventOperatorMatches OverflowEventOperator = "matches"
+	// both are addresses, they are compared without caring about prefix, case or leading zeros
+	EventOperatorAddress OverflowEventOperator = "address"
+)
+
+// OverflowEventCondition a condition on the field of an event the Pointer points to
+type OverflowEventCondition struct {
+	// a json pointer into the fields of the event, IE /nft/id
+	Pointer  string
+	Operator OverflowEventOperator
+	Value    interface{}
+}
+
+// OverflowEventField a json pointer into the fields of an event to create conditions from
+type OverflowEventField string
+
+// EventField start a condition on the field the json pointer points to, IE EventField("/nft/id").Equals(1)
+func EventField(pointer string) OverflowEventField {
+	return OverflowEventField(pointer)
+}
+
+func (f OverflowEventField) condition(operator OverflowEventOperator, value interface{}) OverflowEventCondition {
+	return OverflowEventCondition{Pointer: string(f), Operator: operator, Value: value}
+}
+
+// the field is equal to the value, numbers of different types are equal if they have the same value
+func (f OverflowEventField) Equals(value interface{}) OverflowEventCondition {
+	return f.condition(EventOperatorEquals, value)
+}
+
+// the field is not equal to the value
+func (f OverflowEventField) NotEquals(value interface{}) OverflowEventCondition {
+	return f.condition(EventOperatorNotEquals, value)
+}
+
+// the field is a number greater than the value
+func (f OverflowEventField) GreaterThan(value interface{}) OverflowEventCondition {
+	return f.condition(EventOperatorGreaterThan, value)
+}
+
+// the field is a number greater than or equal to the value
+func (f OverflowEventField) GreaterThanOrEqual(value interface{}) OverflowEventCondition {
+	return f.condition(EventOperatorGreaterThanOrEqual, value)
+}
+
+// the field is a number less than the value
+func (f OverflowEventField) LessThan(value interface{}) OverflowEventCondition {
+	return f.condition(EventOperatorLessThan, value)
+}
+
+// the field is a number less than or equal to the value
+func (f OverflowEventField) LessThanOrEqual(value interface{}) OverflowEventCondition {
+	return f.condition(EventOperatorLessThanOrEqual, value)
+}
+
+// the field is a string containing the value, an array with the value as an element or a dictionary with the value as a key
+func (f OverflowEventField) Contains(value interface{}) OverflowEventCondition {
+	return f.condition(EventOperatorContains, value)
+}
+
+// the field matches the regular expression
+func (f OverflowEventField) Matches(regex string) OverflowEventCondition {
+	return f.condition(EventOperatorMatches, regex)
+}
+
+// the field is the given address
+func (f OverflowEventField) IsAddress(address string) OverflowEventCondition {
+	return f.condition(EventOperatorAddress, address)
+}
+
+// the field is the address of the account or contract with the given name, panics if there is no such account
+func (o *OverflowState) EventFieldIsAccount(pointer string, name string) OverflowEventCondition {
+	return EventField(pointer).IsAddress(o.Address(name))
+}
+
+func (c OverflowEventCondition) String() string {
+	return fmt.Sprintf("%s %s %v", c.Pointer, c.Operator, c.Value)
+}
+
+// Match reports if the event matches the condition, an error is returned if the pointer does not exist or the values cannot be compared
+func (c OverflowEventCondition) Match(event OverflowEvent) (bool, error) {
+	ptr, err := gojsonpointer.NewJsonPointer(c.Pointer)
+	if err != nil {
+		return false, err
+	}
+
+	fields, err := normalizeEventValue(event.Fields)
+	if err != nil {
+		return false, err
+	}
+
+	actual, _, err := ptr.Get(fields)
+	if err != nil {
+		return false, err
+	}
+
+	expected, err := normalizeEventValue(c.Value)
+	if err != nil {
+		return false, err
+	}
+
+	switch c.Operator {
+	case EventOperatorEquals:
+		return eventValuesEqual(actual, expected), nil
+	case EventOperatorNotEquals:
+		return !eventValuesEqual(actual, expected), nil
+	case EventOperatorGreaterThan, EventOperatorGreaterThanOrEqual, EventOperatorLessThan, EventOperatorLessThanOrEqual:
+		return compareNumbers(c.Operator, actual, expected)
+	case EventOperatorContains:
+		switch value := actual.(type) {
+		case string:
+			return strings.Contains(value, fmt.Sprint(expected)), nil
+		case []interface{}:
+			for _, element := range value {
+				if eventValuesEqual(element, expected) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]interface{}:
+			_, ok := value[fmt.Sprint(expected)]
+			return ok, nil
+		default:
+			return false, fmt.Errorf("cannot check if %v of type %T contains a value", actual, actual)
+		}
+	case EventOperatorMatches:
+		regex, err := regexp.Compile(fmt.Sprint(expected))
+		if err != nil {
+			return false, err
+		}
+		return regex.MatchString(fmt.Sprint(actual)), nil
+	case EventOperatorAddress:
+		actualAddress, ok := actual.(string)
+		if !ok {
+			return false, fmt.Errorf("%v of type %T is not an address", actual, actual)
+		}
+		return normalizeAddress(actualAddress) == normalizeAddress(fmt.Sprint(expected)), nil
+	default:
+		return false, fmt.Errorf("unknown operator %s", c.Operator)
+	}
+}
+
+// Where returns the events that match all the conditions, events where a condition cannot be evaluated, IE the pointer does not exist, are left out, IE
+//
+//	result.Events["A.0ae53cb6e3f42a79.FlowToken.TokensDeposited"].Where(EventField("/amount").GreaterThan(1.0))
+func (e OverflowEventList) Where(conditions ...OverflowEventCondition) OverflowEventList {
+	result := OverflowEventList{}
+	for _, event := range e {
+		if event.matchesAll(conditions) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+// WithName returns the events whose name ends with the given suffix
+func (e OverflowEventList) WithName(suffix string) OverflowEventList {
+	result := OverflowEventList{}
+	for _, event := range e {
+		if strings.HasSuffix(event.Name, suffix) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+func (e OverflowEvent) matchesAll(conditions []OverflowEventCondition) bool {
+	for _, condition := range conditions {
+		match, err := condition.Match(e)
+		if err != nil || !match {
+			return false
+		}
+	}
+	return true
+}
+
+// Assert that an event with the given name suffix that matches all the conditions is present
+func (o OverflowResult) AssertEventWhere(t *testing.T, name string, conditions ...OverflowEventCondition) OverflowResult {
+	t.Helper()
+	if len(o.AllEvents().WithName(name).Where(conditions...)) == 0 {
+		assert.Fail(t, fmt.Sprintf("transaction %s missing event %s where %s", o.Name, name, litter.Sdump(conditionStrings(conditions))))
+		o.Events.Print(t)
+	}
+	return o
+}
+
+// Require that an event with the given name suffix that matches all the conditions is present
+func (o OverflowResult) RequireEventWhere(t *testing.T, name string, conditions ...OverflowEventCondition) OverflowResult {
+	t.Helper()
+	if len(o.AllEvents().WithName(name).Where(conditions...)) == 0 {
+		o.Events.Print(t)
+		require.Fail(t, fmt.Sprintf("transaction %s missing event %s where %s", o.Name, name, litter.Sdump(conditionStrings(conditions))))
+	}
+	return o
+}
+
+func conditionStrings(conditions []OverflowEventCondition) []string {
+	result := []string{}
+	for _, condition := range conditions {
+		result = append(result, condition.String())
+	}
+	return result
+}
+
+// turn a value into the form it has after a json roundtrip so that values of different go types can be compared,
+// numbers are kept as json.Number so large integers do not lose precision
+func normalizeEventValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var result interface{}
+	err = decoder.Decode(&result)
+	return result, err
+}
+
+// numbers are compared by value so 42, 42.0 and 4.2e1 are equal, everything else has to be deeply equal
+func eventValuesEqual(actual interface{}, expected interface{}) bool {
+	switch a := actual.(type) {
+	case json.Number:
+		b, ok := expected.(json.Number)
+		if !ok {
+			return false
+		}
+		x, errA := parseEventNumber(a.String())
+		y, errB := parseEventNumber(b.String())
+		if errA != nil || errB != nil {
+			return a == b
+		}
+		return x.Cmp(y) == 0
+	case []interface{}:
+		b, ok := expected.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !eventValuesEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		b, ok := expected.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !eventValuesEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(actual, expected)
+	}
+}
+
+func compareNumbers(operator OverflowEventOperator, actual interface{}, expected interface{}) (bool, error) {
+	a, err := eventValueAsNumber(actual)
+	if err != nil {
+		return false, err
+	}
+	b, err := eventValueAsNumber(expected)
+	if err != nil {
+		return false, err
+	}
+
+	switch operator {
+	case EventOperatorGreaterThan:
+		return a.Cmp(b) > 0, nil
+	case EventOperatorGreaterThanOrEqual:
+		return a.Cmp(b) >= 0, nil
+	case EventOperatorLessThan:
+		return a.Cmp(b) < 0, nil
+	default:
+		return a.Cmp(b) <= 0, nil
+	}
+}
+
+// big cadence numbers are strings so we parse those as well
+func eventValueAsNumber(value interface{}) (*big.Float, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return parseEventNumber(v.String())
+	case string:
+		return parseEventNumber(v)
+	default:
+		return nil, fmt.Errorf("%v of type %T is not a number", value, value)
+	}
+}
+
+// 256 bits of precision holds every cadence integer up to UInt256 exactly
+func parseEventNumber(value string) (*big.Float, error) {
+	number, _, err := big.ParseFloat(value, 10, 256, big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a number: %w", value, err)
+	}
+	return number, nil
+}
+
+func normalizeAddress(address string) string {
+	address = strings.TrimPrefix(strings.ToLower(address), "0x")
+	return strings.TrimLeft(address, "0")
+}
@@ -0,0 +1,130 @@
+package overflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventQuery(t *testing.T) {
+	events := OverflowEventList{
+		{
+			Id:   "1",
+			Name: "A.123.Market.Listed",
+			Fields: map[string]interface{}{
+				"seller": "0x01cf0e2f2f715450",
+				"price":  10.5,
+				"nft": map[string]interface{}{
+					"id":   uint64(42),
+					"tags": []interface{}{"rare", "shiny"},
+					"name": "Bjartek's NFT",
+				},
+			},
+		},
+		{
+			Id:   "2",
+			Name: "A.123.Market.Listed",
+			Fields: map[string]interface{}{
+				"seller": "0x179b6b1cb6755e31",
+				"price":  "1.0",
+				"nft": map[string]interface{}{
+					"id":   uint64(7),
+					"tags": []interface{}{},
+					"name": "Other",
+				},
+			},
+		},
+		{
+			Id:     "3",
+			Name:   "A.123.Market.Sold",
+			Fields: map[string]interface{}{"id": 42},
+		},
+	}
+
+	ids := func(list OverflowEventList) []string {
+		result := []string{}
+		for _, event := range list {
+			result = append(result, event.Id)
+		}
+		return result
+	}
+
+	t.Run("Equals nested field with different number type", func(t *testing.T) {
+		assert.Equal(t, []string{"1"}, ids(events.Where(EventField("/nft/id").Equals(42))))
+	})
+
+	t.Run("Not equals", func(t *testing.T) {
+		assert.Equal(t, []string{"2"}, ids(events.WithName("Listed").Where(EventField("/nft/id").NotEquals(42))))
+	})
+
+	t.Run("Greater and less than", func(t *testing.T) {
+		assert.Equal(t, []string{"1"}, ids(events.Where(EventField("/price").GreaterThan(2))))
+		assert.Equal(t, []string{"2"}, ids(events.Where(EventField("/price").LessThanOrEqual(1.0))))
+		assert.Equal(t, []string{"1", "2"}, ids(events.Where(EventField("/nft/id").GreaterThanOrEqual(7), EventField("/nft/id").LessThan(100))))
+	})
+
+	t.Run("Large numbers keep their precision", func(t *testing.T) {
+		large := OverflowEventList{
+			{Id: "1", Fields: map[string]interface{}{"id": uint64(9007199254740993), "balance": "115792089237316195423570985008687907853269984665640564039457584007913129639935"}},
+			{Id: "2", Fields: map[string]interface{}{"id": uint64(9007199254740992), "balance": "115792089237316195423570985008687907853269984665640564039457584007913129639934"}},
+		}
+		assert.Equal(t, []string{"1"}, ids(large.Where(EventField("/id").Equals(uint64(9007199254740993)))))
+		assert.Equal(t, []string{"1"}, ids(large.Where(EventField("/id").GreaterThan(uint64(9007199254740992)))))
+		assert.Equal(t, []string{"2"}, ids(large.Where(EventField("/balance").LessThan("115792089237316195423570985008687907853269984665640564039457584007913129639935"))))
+	})
+
+	t.Run("Contains", func(t *testing.T) {
+		assert.Equal(t, []string{"1"}, ids(events.Where(EventField("/nft/tags").Contains("rare"))))
+		assert.Equal(t, []string{"1"}, ids(events.Where(EventField("/nft/name").Contains("Bjartek"))))
+		assert.Equal(t, []string{"1", "2"}, ids(events.Where(EventField("/nft").Contains("tags"))))
+	})
+
+	t.Run("Matches", func(t *testing.T) {
+		assert.Equal(t, []string{"2"}, ids(events.Where(EventField("/nft/name").Matches("^Oth"))))
+	})
+
+	t.Run("Address", func(t *testing.T) {
+		assert.Equal(t, []string{"1"}, ids(events.Where(EventField("/seller").IsAddress("1CF0E2F2F715450"))))
+	})
+
+	t.Run("Missing pointer does not match", func(t *testing.T) {
+		assert.Empty(t, events.Where(EventField("/nft/foo").Equals(1)))
+	})
+
+	t.Run("Match errors", func(t *testing.T) {
+		_, err := EventField("/seller").GreaterThan(1).Match(events[0])
+		assert.ErrorContains(t, err, "0x01cf0e2f2f715450 is not a number")
+
+		_, err = EventField("/price").Contains(1).Match(events[0])
+		assert.ErrorContains(t, err, "cannot check if 10.5 of type json.Number contains a value")
+
+		_, err = EventField("/price").IsAddress("0x1").Match(events[0])
+		assert.ErrorContains(t, err, "is not an address")
+
+		_, err = EventField("price").Equals(1).Match(events[0])
+		assert.Error(t, err)
+	})
+
+	t.Run("Condition string", func(t *testing.T) {
+		assert.Equal(t, "/nft/id > 5", EventField("/nft/id").GreaterThan(5).String())
+	})
+}
+
+func TestEventQueryAssertions(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	t.Run("Assert event where", func(t *testing.T) {
+		o.Tx("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", 1.0),
+			WithAssertEventWhere(t, "TokensDeposited", o.EventFieldIsAccount("/to", "first"), EventField("/amount").GreaterThanOrEqual(1.0)),
+			WithRequireEventWhere(t, "TokensMinted", EventField("/amount").Equals(1)),
+		).
+			AssertEventWhere(t, "TokensDeposited", EventField("/to").Matches("^0x179b")).
+			RequireEventWhere(t, "TokensMinted", EventField("/amount").LessThan(2))
+	})
+}
@@ -0,0 +1,153 @@
+package overflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// OverflowEventContainer is implemented by everything in overflow that holds events
+type OverflowEventContainer interface {
+	AllEvents() OverflowEventList
+}
+
+var (
+	_ OverflowEventContainer = OverflowEvents{}
+	_ OverflowEventContainer = OverflowEventList{}
+	_ OverflowEventContainer = OverflowResult{}
+	_ OverflowEventContainer = OverflowTransaction{}
+	_ OverflowEventContainer = OverflowBlockEvents{}
+)
+
+type overflowEventRegistry struct {
+	types map[reflect.Type]string
+	mutex sync.RWMutex
+}
+
+var eventRegistry = &overflowEventRegistry{types: map[reflect.Type]string{}}
+
+// RegisterEvent registers the type T for events whose type ends with the given suffix, IE RegisterEvent[NFTDeposited]("NonFungibleToken.Deposited")
+//
+// The fields of the event are decoded into T using json, so use json struct tags if the names differ
+func RegisterEvent[T any](suffix string) {
+	eventRegistry.mutex.Lock()
+	defer eventRegistry.mutex.Unlock()
+	eventRegistry.types[reflect.TypeFor[T]()] = suffix
+}
+
+// RegisteredEventSuffix returns the suffix the type T is registered with
+func RegisteredEventSuffix[T any]() (string, bool) {
+	eventRegistry.mutex.RLock()
+	defer eventRegistry.mutex.RUnlock()
+	suffix, ok := eventRegistry.types[reflect.TypeFor[T]()]
+	return suffix, ok
+}
+
+// TypedEvents decodes all events in the container that match the suffix T is registered with
+func TypedEvents[T any](container OverflowEventContainer) ([]T, error) {
+	suffix, ok := RegisteredEventSuffix[T]()
+	if !ok {
+		return nil, fmt.Errorf("type %s is not registered as an event, use RegisterEvent", reflect.TypeFor[T]())
+	}
+
+	result := []T{}
+	for _, event := range container.AllEvents() {
+		if !strings.HasSuffix(event.Name, suffix) {
+			continue
+		}
+		var value T
+		err := event.MarshalFieldsAs(&value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decode event %s into %s", event.Id, reflect.TypeFor[T]())
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// DecodeRegisteredEvents decodes all events in the container that have a registered type, events without a registered type are skipped.
+// If the suffixes of several types match an event the type with the longest suffix is used
+func DecodeRegisteredEvents(container OverflowEventContainer) ([]interface{}, error) {
+	eventRegistry.mutex.RLock()
+	defer eventRegistry.mutex.RUnlock()
+
+	result := []interface{}{}
+	for _, event := range container.AllEvents() {
+		eventType, ok := eventRegistry.match(event.Name)
+		if !ok {
+			continue
+		}
+		value := reflect.New(eventType)
+		err := event.MarshalFieldsAs(value.Interface())
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decode event %s into %s", event.Id, eventType)
+		}
+		result = append(result, value.Elem().Interface())
+	}
+	return result, nil
+}
+
+// the registered type with the longest suffix that matches the event name, types with the same suffix are picked by name so the choice is stable
+func (r *overflowEventRegistry) match(name string) (reflect.Type, bool) {
+	var match reflect.Type
+	matchSuffix := ""
+	for eventType, suffix := range r.types {
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		if match == nil || len(suffix) > len(matchSuffix) || (len(suffix) == len(matchSuffix) && eventType.String() < match.String()) {
+			match = eventType
+			matchSuffix = suffix
+		}
+	}
+	return match, match != nil
+}
+
+// Marshal the fields of the event as the given type
+func (e OverflowEvent) MarshalFieldsAs(marshalTo interface{}) error {
+	bytes, err := json.Marshal(e.Fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, marshalTo)
+}
+
+// all events in the order they were emitted in their block, events from several blocks are not kept apart so use the EventList of each block for those
+func (overflowEvents OverflowEvents) AllEvents() OverflowEventList {
+	events := OverflowEventList{}
+	for _, eventList := range overflowEvents {
+		events = append(events, eventList...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].TransactionIndex != events[j].TransactionIndex {
+			return events[i].TransactionIndex < events[j].TransactionIndex
+		}
+		return events[i].EventIndex < events[j].EventIndex
+	})
+	return events
+}
+
+func (e OverflowEventList) AllEvents() OverflowEventList {
+	return e
+}
+
+// all events emitted in the transaction after filtering
+func (o OverflowResult) AllEvents() OverflowEventList {
+	return o.Events.AllEvents()
+}
+
+// all events emitted in the transaction except fee events
+func (tx OverflowTransaction) AllEvents() OverflowEventList {
+	return tx.Events
+}
+
+// all events emitted in the block in the order they were emitted
+func (b OverflowBlockEvents) AllEvents() OverflowEventList {
+	return b.EventList
+}
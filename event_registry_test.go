@@ -0,0 +1,104 @@
+package overflow
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tokensDeposited struct {
+	To     string  `json:"to"`
+	Amount float64 `json:"amount"`
+}
+
+type tokensMinted struct {
+	Amount float64 `json:"amount"`
+}
+
+type notRegistered struct{}
+
+type anyTokensDeposited struct {
+	Amount float64 `json:"amount"`
+}
+
+func TestEventRegistry(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	RegisterEvent[tokensDeposited]("FlowToken.TokensDeposited")
+	RegisterEvent[tokensMinted]("FlowToken.TokensMinted")
+
+	result := o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+
+	t.Run("Registered suffix", func(t *testing.T) {
+		suffix, ok := RegisteredEventSuffix[tokensDeposited]()
+		assert.True(t, ok)
+		assert.Equal(t, "FlowToken.TokensDeposited", suffix)
+	})
+
+	t.Run("Typed events from result", func(t *testing.T) {
+		events, err := TypedEvents[tokensDeposited](result)
+		require.NoError(t, err)
+		assert.Contains(t, events, tokensDeposited{To: "0x179b6b1cb6755e31", Amount: 1.0})
+	})
+
+	t.Run("Typed events from crawled transaction", func(t *testing.T) {
+		crawled := 0
+		err := o.CrawlTransactions(func(tx OverflowTransaction) error {
+			crawled++
+			events, err := TypedEvents[tokensMinted](tx)
+			require.NoError(t, err)
+			assert.Equal(t, []tokensMinted{{Amount: 1.0}}, events)
+			return nil
+		}, WithCrawlFromHeight(6), WithCrawlToHeight(6), WithTransactionFilter(EventNameFilter("TokensMinted")))
+		require.NoError(t, err)
+		assert.Equal(t, 1, crawled)
+	})
+
+	t.Run("Decode all registered events", func(t *testing.T) {
+		events, err := DecodeRegisteredEvents(result)
+		require.NoError(t, err)
+		assert.Contains(t, events, tokensMinted{Amount: 1.0})
+		assert.Contains(t, events, tokensDeposited{To: "0x179b6b1cb6755e31", Amount: 1.0})
+	})
+
+	t.Run("Longest registered suffix is used", func(t *testing.T) {
+		RegisterEvent[anyTokensDeposited]("TokensDeposited")
+
+		eventType, ok := eventRegistry.match("A.0ae53cb6e3f42a79.FlowToken.TokensDeposited")
+		require.True(t, ok)
+		assert.Equal(t, reflect.TypeFor[tokensDeposited](), eventType)
+
+		for i := 0; i < 10; i++ {
+			events, err := DecodeRegisteredEvents(result)
+			require.NoError(t, err)
+			assert.Contains(t, events, tokensDeposited{To: "0x179b6b1cb6755e31", Amount: 1.0})
+			assert.NotContains(t, events, anyTokensDeposited{Amount: 1.0})
+		}
+	})
+
+	t.Run("All events in the order they were emitted", func(t *testing.T) {
+		events := OverflowEvents{
+			"A.1.Market.Sold": {
+				{Id: "ff-1", TransactionId: "0xff", TransactionIndex: 0, EventIndex: 1},
+			},
+			"A.1.Market.Listed": {
+				{Id: "00-0", TransactionId: "0x00", TransactionIndex: 1, EventIndex: 0},
+				{Id: "ff-0", TransactionId: "0xff", TransactionIndex: 0, EventIndex: 0},
+			},
+		}
+		ids := []string{}
+		for _, event := range events.AllEvents() {
+			ids = append(ids, event.Id)
+		}
+		assert.Equal(t, []string{"ff-0", "ff-1", "00-0"}, ids)
+	})
+
+	t.Run("Type not registered", func(t *testing.T) {
+		_, err := TypedEvents[notRegistered](result)
+		assert.ErrorContains(t, err, "is not registered as an event")
+	})
+}
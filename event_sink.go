@@ -0,0 +1,287 @@
+package overflow
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EventSink is something that consumes events, fetched events are written to it in batches in the order they were emitted.
+// All sinks are flushed before the progress file is updated so a crash never skips events, it can only make them be written again
+type EventSink interface {
+	// Write a batch of events, a sink may buffer them until Flush is called
+	Write(events []OverflowEvent) error
+	// Flush all buffered events, called every time the progress file is about to be updated
+	Flush() error
+	// Close the sink, the fetcher never closes sinks so the caller that created it must
+	Close() error
+}
+
+// the format events are written in by the built in sinks, the raw cadence event is left out
+type eventSinkRecord struct {
+	Fields        map[string]interface{} `json:"fields"`
+	Addresses     map[string][]string    `json:"addresses"`
+	Id            string                 `json:"id"`
+	TransactionId string                 `json:"transactionID"`
+	Name          string                 `json:"name"`
+	EventIndex    uint32                 `json:"eventIndex"`
+}
+
+func newEventSinkRecord(event OverflowEvent) eventSinkRecord {
+	return eventSinkRecord{
+		Fields:        event.Fields,
+		Addresses:     event.Addresses,
+		Id:            event.Id,
+		TransactionId: event.TransactionId,
+		Name:          event.Name,
+		EventIndex:    event.EventIndex,
+	}
+}
+
+// CallbackEventSink an EventSink that calls the function with every batch of events
+type CallbackEventSink func(events []OverflowEvent) error
+
+func (f CallbackEventSink) Write(events []OverflowEvent) error {
+	return f(events)
+}
+
+func (f CallbackEventSink) Flush() error {
+	return nil
+}
+
+func (f CallbackEventSink) Close() error {
+	return nil
+}
+
+// JSONLEventSink an EventSink that appends one json object per event to a file
+type JSONLEventSink struct {
+	file   *os.File
+	writer *bufio.Writer
+}
+
+// NewJSONLEventSink open or create the given file and append events to it as json lines
+func NewJSONLEventSink(fileName string) (*JSONLEventSink, error) {
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open event sink file %s", fileName)
+	}
+	return &JSONLEventSink{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+func (s *JSONLEventSink) Write(events []OverflowEvent) error {
+	encoder := json.NewEncoder(s.writer)
+	for _, event := range events {
+		err := encoder.Encode(newEventSinkRecord(event))
+		if err != nil {
+			return errors.Wrapf(err, "could not write event %s", event.Id)
+		}
+	}
+	return nil
+}
+
+func (s *JSONLEventSink) Flush() error {
+	err := s.writer.Flush()
+	if err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *JSONLEventSink) Close() error {
+	err := s.Flush()
+	if err != nil {
+		return err
+	}
+	return s.file.Close()
+}
+
+// the header of the csv files written by CSVEventSink
+var CSVEventSinkHeader = []string{"id", "transactionId", "name", "eventIndex", "fields", "addresses"}
+
+// CSVEventSink an EventSink that appends one row per event to a csv file, fields and addresses are written as json
+type CSVEventSink struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+// NewCSVEventSink open or create the given file and append events to it as csv rows, the header is written if the file is empty
+func NewCSVEventSink(fileName string) (*CSVEventSink, error) {
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open event sink file %s", fileName)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	sink := &CSVEventSink{file: file, writer: csv.NewWriter(file)}
+	if stat.Size() == 0 {
+		err := sink.writer.Write(CSVEventSinkHeader)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return sink, nil
+}
+
+func (s *CSVEventSink) Write(events []OverflowEvent) error {
+	for _, event := range events {
+		fields, err := json.Marshal(event.Fields)
+		if err != nil {
+			return errors.Wrapf(err, "could not marshal fields of event %s", event.Id)
+		}
+		addresses, err := json.Marshal(event.Addresses)
+		if err != nil {
+			return errors.Wrapf(err, "could not marshal addresses of event %s", event.Id)
+		}
+		err = s.writer.Write([]string{
+			event.Id,
+			event.TransactionId,
+			event.Name,
+			strconv.FormatUint(uint64(event.EventIndex), 10),
+			string(fields),
+			string(addresses),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "could not write event %s", event.Id)
+		}
+	}
+	return nil
+}
+
+func (s *CSVEventSink) Flush() error {
+	s.writer.Flush()
+	err := s.writer.Error()
+	if err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *CSVEventSink) Close() error {
+	err := s.Flush()
+	if err != nil {
+		return err
+	}
+	return s.file.Close()
+}
+
+// WebhookEventSink an EventSink that POSTs every batch of events as a json array to an url
+type WebhookEventSink struct {
+	Url    string
+	Client *http.Client
+}
+
+// NewWebhookEventSink create a sink that posts events to the given url
+func NewWebhookEventSink(url string) *WebhookEventSink {
+	return &WebhookEventSink{
+		Url:    url,
+		Client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *WebhookEventSink) Write(events []OverflowEvent) error {
+	records := []eventSinkRecord{}
+	for _, event := range events {
+		records = append(records, newEventSinkRecord(event))
+	}
+
+	body, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.Client.Post(s.Url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "could not post events to %s", s.Url)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("could not post events to %s status %d %s", s.Url, resp.StatusCode, string(message))
+	}
+	return nil
+}
+
+func (s *WebhookEventSink) Flush() error {
+	return nil
+}
+
+func (s *WebhookEventSink) Close() error {
+	return nil
+}
+
+// write the events in the blocks to all sinks in batches and flush them
+func (e *OverflowEventFetcherBuilder) writeToSinks(blocks []OverflowBlockEvents) error {
+	if len(e.Sinks) == 0 {
+		return nil
+	}
+
+	batch := []OverflowEvent{}
+	write := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		for _, sink := range e.Sinks {
+			err := sink.Write(batch)
+			if err != nil {
+				return errors.Wrap(err, "could not write events to sink")
+			}
+		}
+		batch = []OverflowEvent{}
+		return nil
+	}
+
+	for _, block := range blocks {
+		for _, event := range block.EventList {
+			batch = append(batch, event)
+			if len(batch) >= e.SinkBatchSize {
+				err := write()
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	err := write()
+	if err != nil {
+		return err
+	}
+
+	for _, sink := range e.Sinks {
+		err := sink.Flush()
+		if err != nil {
+			return errors.Wrap(err, "could not flush events to sink")
+		}
+	}
+	return nil
+}
+
+// write all fetched events to the given sinks before the progress file is updated, can be called multiple times
+func WithEventSink(sinks ...EventSink) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.Sinks = append(e.Sinks, sinks...)
+	}
+}
+
+// the max number of events written to a sink in a single write
+func WithSinkBatchSize(size int) OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.SinkBatchSize = size
+	}
+}
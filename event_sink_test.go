@@ -0,0 +1,118 @@
+package overflow
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSinks(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+
+	mintedEvent := "A.0ae53cb6e3f42a79.FlowToken.TokensMinted"
+	depositedEvent := "A.0ae53cb6e3f42a79.FlowToken.TokensDeposited"
+
+	t.Run("Callback sink gets batches in order", func(t *testing.T) {
+		batches := [][]OverflowEvent{}
+		sink := CallbackEventSink(func(events []OverflowEvent) error {
+			batches = append(batches, events)
+			return nil
+		})
+
+		result := o.FetchEventsWithResult(WithEvent(mintedEvent), WithEvent(depositedEvent), WithFromHeight(6), WithUntilHeight(6), WithEventSink(sink), WithSinkBatchSize(1))
+		require.NoError(t, result.Error)
+		require.Len(t, batches, len(result.Blocks[0].EventList))
+		for i, batch := range batches {
+			require.Len(t, batch, 1)
+			assert.Equal(t, result.Blocks[0].EventList[i].Id, batch[0].Id)
+		}
+		assert.Equal(t, mintedEvent, batches[0][0].Name)
+		assert.NotEmpty(t, batches[0][0].TransactionId)
+	})
+
+	t.Run("JSONL sink", func(t *testing.T) {
+		fileName := filepath.Join(t.TempDir(), "events.jsonl")
+		sink, err := NewJSONLEventSink(fileName)
+		require.NoError(t, err)
+
+		_, err = o.FetchEvents(WithEvent(depositedEvent), WithFromHeight(6), WithUntilHeight(6), WithEventSink(sink))
+		require.NoError(t, err)
+		require.NoError(t, sink.Close())
+
+		content, err := os.ReadFile(fileName)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		// the deposit from the mint and the fee deposits
+		require.Len(t, lines, 3)
+
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+		assert.Equal(t, depositedEvent, record["name"])
+		assert.NotEmpty(t, record["id"])
+		assert.NotEmpty(t, record["transactionID"])
+		assert.Equal(t, map[string]interface{}{"to": []interface{}{"0x179b6b1cb6755e31"}}, record["addresses"])
+	})
+
+	t.Run("CSV sink", func(t *testing.T) {
+		fileName := filepath.Join(t.TempDir(), "events.csv")
+		sink, err := NewCSVEventSink(fileName)
+		require.NoError(t, err)
+
+		_, err = o.FetchEvents(WithEvent(depositedEvent), WithFromHeight(6), WithUntilHeight(6), WithEventSink(sink))
+		require.NoError(t, err)
+		require.NoError(t, sink.Close())
+
+		file, err := os.Open(fileName)
+		require.NoError(t, err)
+		defer file.Close()
+
+		rows, err := csv.NewReader(file).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 4)
+		assert.Equal(t, CSVEventSinkHeader, rows[0])
+		assert.Equal(t, depositedEvent, rows[1][2])
+		assert.Equal(t, `{"to":["0x179b6b1cb6755e31"]}`, rows[1][5])
+	})
+
+	t.Run("Webhook sink", func(t *testing.T) {
+		received := []map[string]interface{}{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		}))
+		defer server.Close()
+
+		_, err := o.FetchEvents(WithEvent(mintedEvent), WithFromHeight(6), WithUntilHeight(6), WithEventSink(NewWebhookEventSink(server.URL)))
+		require.NoError(t, err)
+		require.Len(t, received, 1)
+		assert.Equal(t, mintedEvent, received[0]["name"])
+	})
+
+	t.Run("Webhook sink error does not update progress", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		progressFile := filepath.Join(t.TempDir(), "progress")
+		_, err := o.FetchEvents(WithEvent(mintedEvent), WithFromHeight(6), WithProgressFile(progressFile), WithEventSink(NewWebhookEventSink(server.URL)))
+		assert.ErrorContains(t, err, fmt.Sprintf("could not post events to %s status 500", server.URL))
+
+		progress, err := os.ReadFile(progressFile)
+		require.NoError(t, err)
+		assert.Equal(t, "6", string(progress))
+	})
+}
@@ -0,0 +1,64 @@
+package overflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flowkit/v2"
+	"github.com/pkg/errors"
+)
+
+// OverflowBlockStatus the status a block must have to be seen as the latest block
+type OverflowBlockStatus string
+
+const (
+	// the block has been sealed, this is the default
+	BlockStatusSealed OverflowBlockStatus = "sealed"
+	// the block has been finalized but might not be sealed yet, the emulator seals blocks at once so there this is the same as sealed
+	BlockStatusFinalized OverflowBlockStatus = "finalized"
+)
+
+// GetLatestBlockWithStatus get the latest block that has the given status
+func (o *OverflowState) GetLatestBlockWithStatus(ctx context.Context, status OverflowBlockStatus) (*flow.Block, error) {
+	switch status {
+	case BlockStatusFinalized:
+		if o.AccessClient != nil {
+			block, err := o.AccessClient.GetLatestBlock(ctx, false)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not fetch latest finalized block")
+			}
+			return block, nil
+		}
+	case BlockStatusSealed, "":
+	default:
+		return nil, fmt.Errorf("unknown block status %s", status)
+	}
+
+	return o.Flowkit.GetBlock(ctx, flowkit.LatestBlockQuery)
+}
+
+// GetLatestConfirmedBlock get the block that has the given number of blocks with the given status on top of it
+func (o *OverflowState) GetLatestConfirmedBlock(ctx context.Context, status OverflowBlockStatus, confirmations uint64) (*flow.Block, error) {
+	block, err := o.GetLatestBlockWithStatus(ctx, status)
+	if err != nil || confirmations == 0 {
+		return block, err
+	}
+
+	if block.Height < confirmations {
+		return nil, fmt.Errorf("no block has %d confirmations yet, the latest %s block is at height %d", confirmations, status, block.Height)
+	}
+	return o.GetBlockAtHeight(ctx, block.Height-confirmations)
+}
+
+// the height of the latest block with the given status minus the number of confirmations, false if there is no such block yet
+func (o *OverflowState) latestConfirmedHeight(ctx context.Context, status OverflowBlockStatus, confirmations uint64) (uint64, bool, error) {
+	block, err := o.GetLatestBlockWithStatus(ctx, status)
+	if err != nil {
+		return 0, false, errors.Wrap(err, "could not fetch latest block")
+	}
+	if block.Height < confirmations {
+		return 0, false, nil
+	}
+	return block.Height - confirmations, true, nil
+}
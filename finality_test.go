@@ -0,0 +1,86 @@
+package overflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinality(t *testing.T) {
+	mintedEvent := "A.0ae53cb6e3f42a79.FlowToken.TokensMinted"
+	ctx := context.Background()
+
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+
+	t.Run("Latest block with status", func(t *testing.T) {
+		sealed, err := o.GetLatestBlockWithStatus(ctx, BlockStatusSealed)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(6), sealed.Height)
+
+		finalized, err := o.GetLatestBlockWithStatus(ctx, BlockStatusFinalized)
+		require.NoError(t, err)
+		assert.Equal(t, sealed.ID, finalized.ID)
+
+		_, err = o.GetLatestBlockWithStatus(ctx, OverflowBlockStatus("foo"))
+		assert.ErrorContains(t, err, "unknown block status foo")
+	})
+
+	t.Run("Latest confirmed block", func(t *testing.T) {
+		block, err := o.GetLatestConfirmedBlock(ctx, BlockStatusSealed, 2)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(4), block.Height)
+
+		_, err = o.GetLatestConfirmedBlock(ctx, BlockStatusSealed, 100)
+		assert.ErrorContains(t, err, "no block has 100 confirmations yet, the latest sealed block is at height 6")
+	})
+
+	t.Run("Fetch events holds back unconfirmed blocks", func(t *testing.T) {
+		result := o.FetchEventsWithResult(WithEvent(mintedEvent), WithFromHeight(5), WithFetchConfirmationDepth(1))
+		require.NoError(t, result.Error)
+		assert.Equal(t, uint64(5), result.To)
+		assert.Empty(t, result.Events)
+
+		_, err := o.FetchEvents(WithEvent(mintedEvent), WithFetchConfirmationDepth(100))
+		assert.ErrorContains(t, err, "no block has 100 confirmations yet")
+	})
+
+	t.Run("Crawl holds back unconfirmed blocks", func(t *testing.T) {
+		err := o.CrawlTransactions(func(tx OverflowTransaction) error { return nil }, WithCrawlFromHeight(6), WithCrawlConfirmationDepth(1))
+		assert.ErrorContains(t, err, "cannot crawl from height 6 since it is after the end height 5")
+	})
+
+	t.Run("Subscription only sends confirmed events", func(t *testing.T) {
+		o, err := OverflowTesting(WithConfirmationDepth(1))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events, errs := o.SubscribeEvents(ctx, OverflowEventFilter{mintedEvent: {}}, WithPollInterval(10*time.Millisecond))
+
+		o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+
+		select {
+		case event := <-events:
+			require.Fail(t, "received event before it was confirmed", event.Id)
+		case err := <-errs:
+			require.NoError(t, err)
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 2.0)).AssertSuccess(t)
+
+		select {
+		case event := <-events:
+			assert.Equal(t, 1.0, event.Fields["amount"])
+		case err := <-errs:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "timed out waiting for events")
+		}
+	})
+}
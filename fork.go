@@ -0,0 +1,376 @@
+package overflow
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/onflow/cadence/common"
+	cadenceRuntime "github.com/onflow/cadence/runtime"
+	"github.com/onflow/flow-emulator/storage"
+	"github.com/onflow/flow-emulator/storage/sqlite"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go/fvm/environment"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow/protobuf/go/flow/entities"
+	"github.com/onflow/flow/protobuf/go/flow/executiondata"
+	"github.com/onflow/flowkit/v2/accounts"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// how far behind the latest sealed block the state is exported, the registers of the newest blocks may not be indexed yet
+const forkHeightBuffer = 10
+
+// how many registers are fetched from an access node at once
+const forkRegisterBatchSize = 100
+
+// OverflowNetworkExport the state of some accounts on a network at a height
+type OverflowNetworkExport struct {
+	Network string `json:"network"`
+	ChainID string `json:"chainId"`
+	Height  uint64 `json:"height"`
+	// the address of the exported accounts by the name they were exported with
+	Accounts  map[string]string      `json:"accounts"`
+	Registers []OverflowForkRegister `json:"registers"`
+}
+
+// OverflowForkRegister a hex encoded register, registers without an owner are global
+type OverflowForkRegister struct {
+	Owner string `json:"owner"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// reads registers at the height that is exported, registers that do not exist have no value
+type forkRegisterReader func(ctx context.Context, ids flowgo.RegisterIDs) ([]flowgo.RegisterValue, error)
+
+// ExportNetworkState write the accounts, contracts and storage of the given accounts to a file that can be started with WithForkedState.
+// Accounts are names from flow.json, contract names or addresses
+func (o *OverflowState) ExportNetworkState(ctx context.Context, file string, accountNames ...string) (*OverflowNetworkExport, error) {
+	export := &OverflowNetworkExport{
+		Network:  o.Network.Name,
+		Accounts: map[string]string{},
+	}
+
+	var read forkRegisterReader
+	if o.Emulator != nil {
+		block, err := o.Emulator.GetLatestBlock()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get latest block")
+		}
+		export.ChainID = o.Emulator.GetChain().ChainID().String()
+		export.Height = block.Height
+		read = func(_ context.Context, ids flowgo.RegisterIDs) ([]flowgo.RegisterValue, error) {
+			return o.Emulator.GetRegisterValues(ids, export.Height)
+		}
+	} else if o.AccessClient != nil {
+		parameters, err := o.AccessClient.GetNetworkParameters(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get network parameters of %s", o.Network.Name)
+		}
+		block, err := o.AccessClient.GetLatestBlockHeader(ctx, true)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get latest sealed block of %s", o.Network.Name)
+		}
+		export.ChainID = parameters.ChainID.String()
+		export.Height = block.Height
+		if export.Height > forkHeightBuffer {
+			export.Height -= forkHeightBuffer
+		}
+		read = o.networkRegisterReader(export.Height)
+	} else {
+		return nil, fmt.Errorf("network state can only be exported from an access node or the in memory emulator")
+	}
+
+	// accounts created in the fork get addresses after the ones that already exist
+	addressState, err := read(ctx, flowgo.RegisterIDs{flowgo.AddressStateRegisterID})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read address state")
+	}
+	export.addRegister(flowgo.AddressStateRegisterID, addressState[0])
+
+	for _, name := range accountNames {
+		address, err := o.exportAddress(name)
+		if err != nil {
+			return nil, err
+		}
+		err = export.addAccount(ctx, read, address)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not export account %s", name)
+		}
+		export.Accounts[name] = address.HexWithPrefix()
+	}
+
+	content, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	err = os.WriteFile(file, content, 0o644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not write network state to %s", file)
+	}
+	return export, nil
+}
+
+// the address of an account to export, resolved like addresses in arguments
+func (o *OverflowState) exportAddress(name string) (flow.Address, error) {
+	address, err := hexToAddress(name)
+	if err == nil {
+		return flow.Address(*address), nil
+	}
+	flowAddress, err := o.FlowAddressE(name)
+	if err != nil {
+		return flow.EmptyAddress, errors.Wrapf(err, "could not parse %s into an address", name)
+	}
+	return *flowAddress, nil
+}
+
+// read registers one batch at a time from the execution data api of the access node
+func (o *OverflowState) networkRegisterReader(height uint64) forkRegisterReader {
+	client := o.AccessClient.ExecutionDataRPCClient()
+
+	get := func(ctx context.Context, ids flowgo.RegisterIDs) ([][]byte, error) {
+		messages := []*entities.RegisterID{}
+		for _, id := range ids {
+			messages = append(messages, &entities.RegisterID{Owner: []byte(id.Owner), Key: []byte(id.Key)})
+		}
+		response, err := client.GetRegisterValues(ctx, &executiondata.GetRegisterValuesRequest{
+			BlockHeight: height,
+			RegisterIds: messages,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return response.Values, nil
+	}
+
+	return func(ctx context.Context, ids flowgo.RegisterIDs) ([]flowgo.RegisterValue, error) {
+		values := make([]flowgo.RegisterValue, len(ids))
+		for start := 0; start < len(ids); start += forkRegisterBatchSize {
+			end := min(start+forkRegisterBatchSize, len(ids))
+			batch, err := get(ctx, ids[start:end])
+			if err == nil && len(batch) == end-start {
+				for i, value := range batch {
+					values[start+i] = value
+				}
+				continue
+			}
+			if err != nil && status.Code(err) != codes.NotFound {
+				return nil, err
+			}
+
+			// a batch with a register that does not exist is not found, so we ask for them one at a time
+			for i := start; i < end; i++ {
+				value, err := get(ctx, ids[i:i+1])
+				if status.Code(err) == codes.NotFound {
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				if len(value) > 0 {
+					values[i] = value[0]
+				}
+			}
+		}
+		return values, nil
+	}
+}
+
+// add the registers of an account, its status tells how many keys it has and how many storage slabs there are
+func (e *OverflowNetworkExport) addAccount(ctx context.Context, read forkRegisterReader, address flow.Address) error {
+	owner := flowgo.BytesToAddress(address.Bytes())
+	registerID := func(key string) flowgo.RegisterID {
+		return flowgo.NewRegisterID(owner, key)
+	}
+
+	ids := flowgo.RegisterIDs{
+		flowgo.AccountStatusRegisterID(owner),
+		flowgo.ContractNamesRegisterID(owner),
+		flowgo.AccountPublicKey0RegisterID(owner),
+		registerID(cadenceRuntime.AccountStorageKey),
+	}
+	for _, domain := range common.AllStorageDomains {
+		ids = append(ids, registerID(domain.Identifier()))
+	}
+	values, err := read(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if len(values[0]) == 0 {
+		return fmt.Errorf("account %s does not exist at height %d", address.HexWithPrefix(), e.Height)
+	}
+
+	accountStatus, err := environment.AccountStatusFromBytes(values[0])
+	if err != nil {
+		return errors.Wrap(err, "could not read account status")
+	}
+
+	more := flowgo.RegisterIDs{}
+	if len(values[1]) > 0 {
+		contractNames, err := environment.DecodeContractNames(values[1])
+		if err != nil {
+			return errors.Wrap(err, "could not read contract names")
+		}
+		for _, name := range contractNames {
+			more = append(more, flowgo.ContractRegisterID(owner, name))
+		}
+	}
+
+	keyCount := accountStatus.AccountPublicKeyCount()
+	for keyIndex := uint32(1); keyIndex < keyCount; keyIndex++ {
+		more = append(more, flowgo.AccountPublicKeySequenceNumberRegisterID(owner, keyIndex))
+	}
+	for batch := uint32(0); keyCount > 1 && batch <= (keyCount-1)/environment.MaxPublicKeyCountInBatch; batch++ {
+		more = append(more, flowgo.AccountBatchPublicKeyRegisterID(owner, batch))
+	}
+
+	// storage slabs are stored under $ and their index, the account status has the index of the next one
+	slabIndex := accountStatus.SlabIndex()
+	nextSlab := binary.BigEndian.Uint64(slabIndex[:])
+	for slab := uint64(1); slab < nextSlab; slab++ {
+		key := make([]byte, 9)
+		key[0] = '$'
+		binary.BigEndian.PutUint64(key[1:], slab)
+		more = append(more, registerID(string(key)))
+	}
+
+	moreValues, err := read(ctx, more)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		e.addRegister(id, values[i])
+	}
+	for i, id := range more {
+		e.addRegister(id, moreValues[i])
+	}
+	return nil
+}
+
+func (e *OverflowNetworkExport) addRegister(id flowgo.RegisterID, value flowgo.RegisterValue) {
+	if len(value) == 0 {
+		return
+	}
+	e.Registers = append(e.Registers, OverflowForkRegister{
+		Owner: hex.EncodeToString([]byte(id.Owner)),
+		Key:   hex.EncodeToString([]byte(id.Key)),
+		Value: hex.EncodeToString(value),
+	})
+}
+
+// read a file written by ExportNetworkState
+func readNetworkExport(file string) (*OverflowNetworkExport, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read network state %s", file)
+	}
+	export := &OverflowNetworkExport{}
+	err = json.Unmarshal(content, export)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse network state %s", file)
+	}
+	return export, nil
+}
+
+// write the exported registers to the latest block of the emulator store
+func (e *OverflowNetworkExport) importRegisters(ctx context.Context, store *sqlite.Store) error {
+	height, err := store.LatestBlockHeight(ctx)
+	if err != nil {
+		return errors.Wrap(err, "could not get latest block of fork")
+	}
+
+	for _, register := range e.Registers {
+		owner, err := hex.DecodeString(register.Owner)
+		if err != nil {
+			return errors.Wrapf(err, "could not parse register owner %s", register.Owner)
+		}
+		key, err := hex.DecodeString(register.Key)
+		if err != nil {
+			return errors.Wrapf(err, "could not parse register key %s", register.Key)
+		}
+		value, err := hex.DecodeString(register.Value)
+		if err != nil {
+			return errors.Wrapf(err, "could not parse register value of %s", register.Key)
+		}
+
+		id := flowgo.RegisterID{Owner: string(owner), Key: string(key)}
+		err = store.SetBytesWithVersion(ctx, store.Storage(storage.LedgerStoreName), []byte(id.String()), value, height)
+		if err != nil {
+			return errors.Wrap(err, "could not import network state")
+		}
+	}
+	return nil
+}
+
+// continue the in memory emulator from the exported state, the registers are only imported when the emulator has no state yet
+func (o *OverflowState) startFork(ctx context.Context, store *sqlite.Store, export *OverflowNetworkExport, importRegisters bool) error {
+	if importRegisters {
+		err := export.importRegisters(ctx, store)
+		if err != nil {
+			return err
+		}
+	}
+
+	emulatorAccount, err := o.State.EmulatorServiceAccount()
+	if err != nil {
+		return err
+	}
+	privateKey, err := emulatorAccount.Key.PrivateKey()
+	if err != nil {
+		return errors.Wrap(err, "could not get the key of the service account")
+	}
+
+	// the service account of the chain has the key of the emulator service account
+	serviceAddress := flow.Address(flowgo.ChainID(export.ChainID).Chain().ServiceAddress())
+	serviceAccount, err := o.State.Accounts().ByName(o.ServiceAccountName())
+	if err != nil || serviceAccount.Address != serviceAddress {
+		o.State.Accounts().AddOrUpdate(&accounts.Account{
+			Name:    o.ServiceAccountName(),
+			Address: serviceAddress,
+			Key:     emulatorAccount.Key,
+		})
+	}
+
+	// the exported accounts sign with the service key, signatures are not checked in a fork
+	signer, err := NewLocalSigner(*privateKey, emulatorAccount.Key.HashAlgo())
+	if err != nil {
+		return err
+	}
+	for name, address := range export.Accounts {
+		if _, err := hexToAddress(name); err == nil {
+			continue
+		}
+		_, err := o.AddSignerAccount(name, address, 0, emulatorAccount.Key.SigAlgo(), emulatorAccount.Key.HashAlgo(), signer)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithForkedState start the in memory emulator with the network state in a file written by ExportNetworkState.
+// Overflow uses the network of the export, so accounts and contract aliases of that network are resolved, IE
+//
+//	mainnet := Overflow(WithNetwork("mainnet"))
+//	mainnet.ExportNetworkState(ctx, "mainnet.json", "0x921ea449dffec68a", "FungibleToken")
+//
+//	o := Overflow(WithForkedState("mainnet.json"))
+//	o.Tx("buy", WithSigner("0x921ea449dffec68a"), ...)
+//
+// The emulator does not check signatures in a fork, the exported accounts and the service account all sign with the service key.
+// Accounts are not created and contracts are not deployed, use CreateAccountsE and InitializeContracts to do that on top of the fork
+func WithForkedState(file string) OverflowOption {
+	return func(o *OverflowBuilder) {
+		o.ForkedState = file
+		o.InMemory = true
+		o.DeployContracts = false
+		o.InitializeAccounts = false
+	}
+}
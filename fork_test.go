@@ -0,0 +1,74 @@
+package overflow
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForkedState(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	balance := func(t *testing.T, o *OverflowState) float64 {
+		value, err := o.Script(`access(all) fun main(address: Address): UFix64 { return getAccount(address).balance }`, WithArg("address", "first")).GetAsInterface()
+		require.NoError(t, err)
+		return value.(float64)
+	}
+
+	message := func(t *testing.T, o *OverflowState) interface{} {
+		value, err := o.Script(`access(all) fun main(address: Address): String? { return getAuthAccount<auth(Storage) &Account>(address).storage.copy<String>(from: /storage/message) }`, WithArg("address", "first")).GetAsInterface()
+		require.NoError(t, err)
+		return value
+	}
+
+	store := `transaction(message: String) { prepare(signer: auth(Storage) &Account) { signer.storage.load<String>(from: /storage/message); signer.storage.save(message, to: /storage/message) } }`
+
+	o.Tx("mint_tokens",
+		WithSignerServiceAccount(),
+		WithArg("recipient", "first"),
+		WithArg("amount", 10.0),
+	).AssertSuccess(t)
+	o.Tx(store, WithSigner("first"), WithArg("message", "exported")).AssertSuccess(t)
+
+	file := filepath.Join(t.TempDir(), "state.json")
+	export, err := o.ExportNetworkState(context.Background(), file, "first")
+	require.NoError(t, err)
+	assert.Equal(t, "emulator", export.Network)
+	assert.Equal(t, map[string]string{"first": o.Address("first")}, export.Accounts)
+
+	t.Run("Start fork", func(t *testing.T) {
+		fork, err := OverflowTesting(WithForkedState(file))
+		require.NoError(t, err)
+		require.NotNil(t, fork.Fork)
+		assert.Equal(t, export.Height, fork.Fork.Height)
+
+		assert.InDelta(t, balance(t, o), balance(t, fork), 0.0001)
+		assert.Equal(t, "exported", message(t, fork))
+
+		fork.Tx(store, WithSigner("first"), WithArg("message", "forked")).AssertSuccess(t)
+		assert.Equal(t, "forked", message(t, fork))
+		assert.Equal(t, "exported", message(t, o))
+	})
+
+	t.Run("Unknown account", func(t *testing.T) {
+		_, err := o.ExportNetworkState(context.Background(), file, "0x1234567890abcdef")
+		assert.ErrorContains(t, err, "account 0x1234567890abcdef does not exist")
+	})
+
+	t.Run("Only from a network", func(t *testing.T) {
+		network := *o
+		network.Emulator = nil
+		_, err := network.ExportNetworkState(context.Background(), file, "first")
+		assert.ErrorContains(t, err, "network state can only be exported from an access node or the in memory emulator")
+	})
+
+	t.Run("Only in memory", func(t *testing.T) {
+		_, err := OverflowTesting(WithForkedState(file), WithExistingEmulator())
+		assert.ErrorContains(t, err, "forked state only works with the in memory emulator")
+	})
+}
@@ -0,0 +1,92 @@
+package overflow
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// the highest gas limit a transaction can have
+const maxGasLimit = 9999
+
+// OverflowGasEstimate the computation a transaction used when it was run as a dry run
+type OverflowGasEstimate struct {
+	// the gas that counts against the gas limit, this includes deducting fees
+	Gas uint64
+	// the computation of the transaction itself as reported in the emulator log
+	ComputationUsed uint64
+	// the execution effort in the fee event in the same unit as gas, zero if fees are disabled
+	FeeGas int
+}
+
+// Limit the gas limit to use with the given margin in percent on top of the gas used
+func (e OverflowGasEstimate) Limit(marginPercent int) uint64 {
+	limit := (e.Gas*uint64(100+marginPercent) + 99) / 100
+	if limit < 1 {
+		return 1
+	}
+	if limit > maxGasLimit {
+		return maxGasLimit
+	}
+	return limit
+}
+
+// EstimateGas run the interaction as a dry run with the highest gas limit and report the computation it used, only works on the in memory emulator
+func (oib OverflowInteractionBuilder) EstimateGas() (*OverflowGasEstimate, error) {
+	if oib.Overflow.EmulatorGatway == nil {
+		return nil, fmt.Errorf("gas can only be estimated on the in memory emulator")
+	}
+
+	oib.DryRun = true
+	oib.GasLimit = maxGasLimit
+	result := oib.sendDryRun()
+	if result.Err != nil {
+		return nil, errors.Wrap(result.Err, "could not estimate gas")
+	}
+
+	computation := uint64(result.ComputationUsed)
+	if computation == 0 && result.TransactionResult != nil {
+		computation = result.TransactionResult.ComputationUsage
+	}
+	gas := computation
+	if uint64(result.FeeGas) > gas {
+		gas = uint64(result.FeeGas)
+	}
+	return &OverflowGasEstimate{
+		Gas:             gas,
+		ComputationUsed: computation,
+		FeeGas:          result.FeeGas,
+	}, nil
+}
+
+// EstimateGas estimate the gas the transaction in the given file uses by running it as a dry run on the in memory emulator, IE
+//
+//	estimate, _ := o.EstimateGas("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0))
+//	o.Tx("mint_tokens", ..., WithAutoGas(20))
+func (o *OverflowState) EstimateGas(filename string, opts ...OverflowInteractionOption) (*OverflowGasEstimate, error) {
+	ftb := o.BuildInteraction(filename, "transaction", opts...)
+	if ftb.Error != nil {
+		return nil, ftb.Error
+	}
+	return ftb.EstimateGas()
+}
+
+// set the gas limit from an estimate before sending, with the given margin in percent on top
+func (oib *OverflowInteractionBuilder) applyAutoGas() error {
+	if oib.AutoGasMargin == nil || oib.Error != nil {
+		return nil
+	}
+	estimate, err := oib.EstimateGas()
+	if err != nil {
+		return err
+	}
+	oib.GasLimit = estimate.Limit(*oib.AutoGasMargin)
+	return nil
+}
+
+// estimate the gas of the transaction on the in memory emulator and use it as gas limit with the given margin in percent on top
+func WithAutoGas(marginPercent int) OverflowInteractionOption {
+	return func(oib *OverflowInteractionBuilder) {
+		oib.AutoGasMargin = &marginPercent
+	}
+}
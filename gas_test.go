@@ -0,0 +1,65 @@
+package overflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasEstimateLimit(t *testing.T) {
+	assert.Equal(t, uint64(120), OverflowGasEstimate{Gas: 100}.Limit(20))
+	assert.Equal(t, uint64(12), OverflowGasEstimate{Gas: 11}.Limit(5))
+	assert.Equal(t, uint64(1), OverflowGasEstimate{Gas: 0}.Limit(20))
+	assert.Equal(t, uint64(9999), OverflowGasEstimate{Gas: 9000}.Limit(50))
+}
+
+func TestGasEstimation(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	mintOpts := []OverflowInteractionOption{
+		WithSignerServiceAccount(),
+		WithArg("recipient", "first"),
+		WithArg("amount", 1.0),
+	}
+
+	t.Run("Estimate gas", func(t *testing.T) {
+		before, err := o.GetLatestBlock(context.Background())
+		require.NoError(t, err)
+
+		estimate, err := o.EstimateGas("mint_tokens", mintOpts...)
+		require.NoError(t, err)
+		assert.Greater(t, estimate.ComputationUsed, uint64(0))
+		assert.Equal(t, uint64(estimate.FeeGas), estimate.Gas)
+
+		after, err := o.GetLatestBlock(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, before.Height, after.Height)
+	})
+
+	t.Run("Send with auto gas", func(t *testing.T) {
+		estimate, err := o.EstimateGas("mint_tokens", mintOpts...)
+		require.NoError(t, err)
+
+		result := o.Tx("mint_tokens", append(mintOpts, WithAutoGas(20))...)
+		result.AssertSuccess(t)
+		assert.Equal(t, estimate.Limit(20), result.Transaction.GasLimit)
+		assert.LessOrEqual(t, uint64(result.FeeGas), result.Transaction.GasLimit)
+	})
+
+	t.Run("Estimate failing transaction", func(t *testing.T) {
+		_, err := o.EstimateGas(`transaction { prepare(signer: &Account) { panic("oops") } }`, WithSignerServiceAccount())
+		assert.ErrorContains(t, err, "could not estimate gas")
+		assert.ErrorContains(t, err, "oops")
+	})
+
+	t.Run("Auto gas outside the emulator", func(t *testing.T) {
+		network := *o
+		network.EmulatorGatway = nil
+		result := network.Tx("mint_tokens", append(mintOpts, WithAutoGas(20))...)
+		assert.ErrorContains(t, result.Err, "gas can only be estimated on the in memory emulator")
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"math"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bjartek/underflow"
 	"github.com/enescakir/emoji"
@@ -90,6 +91,25 @@ type OverflowInteractionBuilder struct {
 	Testing OverflowTestingAsssertions
 
 	AutoSigner bool
+
+	// called every time the status of a transaction sent with SendAsync changes
+	StatusCallback func(flow.TransactionStatus)
+
+	// how often to poll for the status of a transaction sent with SendAsync
+	StatusPollInterval time.Duration
+
+	// how many times to retry sending a transaction that fails with a retryable error and how long to wait before the first retry
+	RetryMax     int
+	RetryBackoff time.Duration
+
+	// execute the transaction without keeping the state change, see WithDryRun
+	DryRun bool
+
+	// if set the gas limit is estimated before sending with this margin in percent on top, see WithAutoGas
+	AutoGasMargin *int
+
+	// the name of the sponsor that pays the fees of the transaction, see WithSponsor
+	Sponsor string
 }
 
 type OverflowTestingAsssertions struct {
@@ -100,9 +120,11 @@ type OverflowTestingAsssertions struct {
 }
 
 type EventAssertion struct {
-	Fields  map[string]interface{}
-	Suffix  string
-	Require bool
+	Fields map[string]interface{}
+	Suffix string
+	// if set the event must match these conditions instead of the Fields
+	Conditions []OverflowEventCondition
+	Require    bool
 }
 
 // get the contract code
@@ -466,6 +488,32 @@ func WithRequireEvent(t *testing.T, suffix string, fields map[string]interface{}
 	}
 }
 
+func WithAssertEventWhere(t *testing.T, suffix string, conditions ...OverflowEventCondition) OverflowInteractionOption {
+	return func(oib *OverflowInteractionBuilder) {
+		oib.Testing.T = t
+
+		oib.Testing.Events = append(oib.Testing.Events, EventAssertion{
+			Suffix:     suffix,
+			Conditions: conditions,
+			Require:    false,
+		})
+		oib.Testing.Require = false
+	}
+}
+
+func WithRequireEventWhere(t *testing.T, suffix string, conditions ...OverflowEventCondition) OverflowInteractionOption {
+	return func(oib *OverflowInteractionBuilder) {
+		oib.Testing.T = t
+
+		oib.Testing.Events = append(oib.Testing.Events, EventAssertion{
+			Suffix:     suffix,
+			Conditions: conditions,
+			Require:    true,
+		})
+		oib.Testing.Require = false
+	}
+}
+
 func WithEventAssertions(t *testing.T, ea ...EventAssertion) OverflowInteractionOption {
 	return func(oib *OverflowInteractionBuilder) {
 		oib.Testing.T = t
@@ -476,6 +524,93 @@ func WithEventAssertions(t *testing.T, ea ...EventAssertion) OverflowInteraction
 
 // Send a interaction builder as a Transaction returning an overflow result
 func (oib OverflowInteractionBuilder) Send() *OverflowResult {
+	err := oib.applyAutoGas()
+	if err != nil {
+		oib.Error = err
+	}
+	if oib.DryRun {
+		return oib.sendDryRun()
+	}
+	return oib.sendWithRetry()
+}
+
+// build, sign and send the transaction once
+func (oib OverflowInteractionBuilder) sendOnce() *OverflowResult {
+	result, tx, pending := oib.buildSignedTransaction()
+	if result.Err != nil {
+		return result
+	}
+	result.Transaction = tx.FlowTransaction()
+
+	// the proposer key and emulator are released as soon as the transaction is sent so others can use them while we wait for the result
+	ftx, err := oib.Overflow.Flowkit.Gateway().SendSignedTransaction(oib.Ctx, tx.FlowTransaction())
+	if err != nil {
+		pending.done(false)
+		result.Err = err
+		return result
+	}
+	logMessage, logErr := oib.Overflow.readLog()
+	if pending.block != nil {
+		result.Transaction = ftx
+		result.Pending = true
+		pending.block.add(&oib, result)
+		pending.done(true)
+		return result
+	}
+	pending.done(true)
+
+	res, err := oib.waitForResult(ftx.ID())
+	if err != nil {
+		result.Transaction = ftx
+		result.Err = errors.Wrapf(err, "transaction %s was sent but its result could not be fetched", ftx.ID())
+		result.resultUnknown = true
+		result.sponsorReservation.settle(nil)
+		oib.Overflow.dropSequenceNumber(ftx.ProposalKey)
+		return result
+	}
+	result.Transaction = ftx
+	result.TransactionResult = res
+
+	if logErr != nil {
+		result.Err = logErr
+	}
+	result = oib.processResult(result, logMessage)
+	if res.Error != nil {
+		oib.Overflow.dropSequenceNumber(ftx.ProposalKey)
+	}
+	return result
+}
+
+// build and sign the transaction, if it fails the error is set on the returned result.
+// On success done must be called on the pending send after the transaction is sent and the emulator log read
+func (oib OverflowInteractionBuilder) buildSignedTransaction() (*OverflowResult, *transactions.Transaction, *pendingSend) {
+	result, tx, signers, pending := oib.buildTransaction()
+	if result.Err != nil {
+		return result, nil, nil
+	}
+
+	for _, signer := range signers {
+		err := tx.SetSigner(signer)
+		if err != nil {
+			pending.done(false)
+			result.Err = err
+			return result, nil, nil
+		}
+
+		tx, err = tx.Sign()
+		if err != nil {
+			pending.done(false)
+			result.Err = err
+			return result, nil, nil
+		}
+	}
+	result.Id = tx.FlowTransaction().ID()
+
+	return result, tx, pending
+}
+
+// build the transaction without signing it, the accounts that should sign it are returned in the order they should sign
+func (oib OverflowInteractionBuilder) buildTransaction() (*OverflowResult, *transactions.Transaction, []*accounts.Account, *pendingSend) {
 	result := &OverflowResult{
 		StopOnError:      oib.Overflow.StopOnError,
 		Err:              nil,
@@ -498,24 +633,22 @@ func (oib OverflowInteractionBuilder) Send() *OverflowResult {
 	}
 	if oib.Error != nil {
 		result.Err = oib.Error
-		return result
+		return result, nil, nil, nil
 	}
 
-	codeFileName := fmt.Sprintf("%s/%s.cdc", oib.BasePath, oib.FileName)
-
 	result.DeclarationInfo = *declarationInfo(oib.TransactionCode)
 
 	// if we have more then one should the following be payload signers?
 	if oib.AutoSigner {
 		if len(result.DeclarationInfo.Authorizers) != 1 {
 			result.Err = errors.New("currently do not support more then 1 signer when using authSigner")
-			return result
+			return result, nil, nil, nil
 		}
 
 		account, err := oib.Overflow.AccountE(result.DeclarationInfo.Authorizers[0].Name)
 		if err != nil {
 			result.Err = err
-			return result
+			return result, nil, nil, nil
 		}
 		oib.Payer = account
 		oib.Proposer = account
@@ -523,9 +656,49 @@ func (oib OverflowInteractionBuilder) Send() *OverflowResult {
 
 	if oib.Proposer == nil {
 		result.Err = fmt.Errorf("%v You need to set the proposer signer", emoji.PileOfPoo)
-		return result
+		return result, nil, nil, nil
+	}
+
+	sponsor, err := oib.sponsor()
+	if err != nil {
+		result.Err = err
+		return result, nil, nil, nil
+	}
+
+	// the fee parameters are read with a script so this has to happen before we hold the emulator
+	maxFee, err := oib.sponsorMaxFee(sponsor)
+	if err != nil {
+		result.Err = err
+		return result, nil, nil, nil
+	}
+
+	lease, err := oib.Overflow.leaseProposerKey(oib.Ctx, oib.Proposer)
+	if err != nil {
+		result.Err = err
+		return result, nil, nil, nil
+	}
+	if lease != nil {
+		oib.Proposer = lease.account()
+		if oib.Payer != nil && oib.Payer.Address == oib.Proposer.Address {
+			oib.Payer = oib.Proposer
+		}
+		payloadSigners := []*accounts.Account{}
+		for _, signer := range oib.PayloadSigners {
+			if signer.Address == oib.Proposer.Address {
+				signer = oib.Proposer
+			}
+			payloadSigners = append(payloadSigners, signer)
+		}
+		oib.PayloadSigners = payloadSigners
+	}
+
+	// the emulator expires transactions if a block is committed between building and sending them so we hold it until sent
+	pending := &pendingSend{lease: lease, unlock: oib.Overflow.lockEmulatorLog()}
+	release := func(result *OverflowResult) (*OverflowResult, *transactions.Transaction, []*accounts.Account, *pendingSend) {
+		pending.done(false)
+		return result, nil, nil, nil
 	}
-	oib.Overflow.Log.Reset()
+
 	/*
 		❗ Special case: if an account is both the payer and either a proposer or authorizer, it is only required to sign the envelope.
 	*/
@@ -546,10 +719,30 @@ func (oib OverflowInteractionBuilder) Send() *OverflowResult {
 		signers = append(signers, oib.Proposer)
 	}
 
+	// the sponsor pays for the transaction so it signs the envelope last, the payer we had is still an authorizer
+	if sponsor != nil && sponsor.Account.Address != payer.Address {
+		payer = sponsor.Account
+		if payer.Address == oib.Proposer.Address {
+			payer = oib.Proposer
+		}
+		signers = append(signers, payer)
+		result.sponsored = true
+
+		if !oib.DryRun {
+			reservation, err := sponsor.reserve(maxFee)
+			if err != nil {
+				result.Err = err
+				return release(result)
+			}
+			result.sponsorReservation = reservation
+			pending.reservation = reservation
+		}
+	}
+
 	script := flowkit.Script{
 		Code:     oib.TransactionCode,
 		Args:     oib.Arguments,
-		Location: codeFileName,
+		Location: oib.codeFileName(),
 	}
 
 	addresses := transactions.AddressesRoles{
@@ -567,38 +760,37 @@ func (oib OverflowInteractionBuilder) Send() *OverflowResult {
 	)
 	if err != nil {
 		result.Err = err
-		return result
+		return release(result)
 	}
 
-	for _, signer := range signers {
-		err = tx.SetSigner(signer)
-		if err != nil {
-			result.Err = err
-			return result
-		}
+	if lease != nil {
+		proposalKey := tx.FlowTransaction().ProposalKey
+		tx.FlowTransaction().SetProposalKey(proposalKey.Address, lease.index, lease.useSequenceNumber(proposalKey.SequenceNumber))
+	}
 
-		tx, err = tx.Sign()
-		if err != nil {
-			result.Err = err
-			return result
+	// the sequence number on chain does not include transactions in a block that is not committed yet
+	if block := oib.Overflow.pendingBlock; block != nil {
+		proposalKey := tx.FlowTransaction().ProposalKey
+		if lease == nil {
+			tx.FlowTransaction().SetProposalKey(proposalKey.Address, proposalKey.KeyIndex, block.sequenceNumber(proposalKey))
 		}
+		pending.block = block
+		pending.proposalKey = tx.FlowTransaction().ProposalKey
 	}
-	txId := tx.FlowTransaction().ID()
-	result.Id = txId
 
-	ftx, res, err := oib.Overflow.Flowkit.SendSignedTransaction(oib.Ctx, tx)
-	result.Transaction = ftx
-	result.TransactionResult = res
+	result.Id = tx.FlowTransaction().ID()
 
-	if err != nil {
-		result.Err = err
-		return result
-	}
+	return result, tx, signers, pending
+}
 
-	logMessage, err := oib.Overflow.readLog()
-	if err != nil {
-		result.Err = err
-	}
+// the name of the file the transaction code is read from
+func (oib OverflowInteractionBuilder) codeFileName() string {
+	return fmt.Sprintf("%s/%s.cdc", oib.BasePath, oib.FileName)
+}
+
+// turn the result of a sealed transaction into events, fees and meter info
+func (oib OverflowInteractionBuilder) processResult(result *OverflowResult, logMessage []OverflowEmulatorLogMessage) *OverflowResult {
+	res := result.TransactionResult
 	result.RawLog = logMessage
 
 	result.Meter = &OverflowMeter{}
@@ -611,7 +803,7 @@ func (oib OverflowInteractionBuilder) Send() *OverflowResult {
 		if strings.Contains(msg.Msg, "transaction execution data") {
 			var meter OverflowMeter
 			bytes, _ := json.Marshal(msg.Fields)
-			err = json.Unmarshal(bytes, &meter)
+			err := json.Unmarshal(bytes, &meter)
 			if err == nil {
 				result.Meter = &meter
 			}
@@ -636,8 +828,10 @@ func (oib OverflowInteractionBuilder) Send() *OverflowResult {
 		result.FeeGas = gas
 	}
 
+	// transactions that fail verification, IE with a revoked key, do not pay fees
 	feeAmount := result.Fee["amount"]
-	eventsWithoutFees, feeFromEvents := overflowEvents.FilterFees(feeAmount.(float64), fmt.Sprintf("0x%s", result.Transaction.Payer.Hex()))
+	amount, _ := feeAmount.(float64)
+	eventsWithoutFees, feeFromEvents := overflowEvents.FilterFees(amount, fmt.Sprintf("0x%s", result.Transaction.Payer.Hex()))
 	result.Balance = feeFromEvents
 
 	if !oib.IgnoreGlobalEventFilters {
@@ -660,8 +854,8 @@ func (oib OverflowInteractionBuilder) Send() *OverflowResult {
 	result.Events = overflowEvents
 
 	result.Name = oib.Name
-	oib.Overflow.Log.Reset()
-	result.Err = errors.Wrapf(res.Error, "transaction=%s", codeFileName)
+	result.Err = errors.Wrapf(res.Error, "transaction=%s", oib.codeFileName())
+	oib.Overflow.recordSponsoredFee(result)
 
 	if result.Err != nil && result.StopOnError {
 		panic(result.Err)
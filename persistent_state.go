@@ -0,0 +1,175 @@
+package overflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/onflow/flow-emulator/storage/sqlite"
+	"github.com/pkg/errors"
+)
+
+// the file in the persistent state directory with what overflow has set up on top of the emulator state
+const persistentStateFile = "overflow.json"
+
+// the name sqlite gives the database when the store is a directory
+const persistentStateDatabase = "emulator.sqlite"
+
+// the prefix of the files the sqlite store keeps snapshots in when the store is a directory
+const persistentStateSnapshotPrefix = "snapshot_"
+
+// what overflow has set up in a persistent state directory that the emulator does not know about
+type overflowPersistentState struct {
+	// the key indexes of proposer key pools by the address of the account
+	ProposerKeyPools map[string][]uint32 `json:"proposerKeyPools,omitempty"`
+	// the emulator snapshots of the named snapshots and the one we continue on after restoring a snapshot
+	Snapshots       map[string]string `json:"snapshots,omitempty"`
+	SnapshotCount   int               `json:"snapshotCount,omitempty"`
+	CurrentSnapshot string            `json:"currentSnapshot,omitempty"`
+}
+
+// open the emulator store in the directory, the persisted state is nil if the directory has no state yet
+func openPersistentState(dir string) (*sqlite.Store, *overflowPersistentState, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not create persistent state directory %s", dir)
+	}
+
+	var persisted *overflowPersistentState
+	if _, err := os.Stat(filepath.Join(dir, persistentStateDatabase)); err == nil {
+		persisted = &overflowPersistentState{}
+		content, err := os.ReadFile(filepath.Join(dir, persistentStateFile))
+		if err == nil {
+			err = json.Unmarshal(content, persisted)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "could not read %s in %s", persistentStateFile, dir)
+			}
+		}
+	}
+
+	store, err := sqlite.New(dir)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not open persistent state in %s", dir)
+	}
+	return store, persisted, nil
+}
+
+// continue with the snapshots that were set up the last time the directory was used
+func (o *OverflowState) reopenPersistentState(persisted *overflowPersistentState) error {
+	if persisted.Snapshots != nil {
+		o.snapshots.names = persisted.Snapshots
+	}
+	o.snapshots.count = persisted.SnapshotCount
+	o.snapshots.current = persisted.CurrentSnapshot
+	if o.snapshots.current == "" {
+		return nil
+	}
+	err := o.Emulator.LoadSnapshot(o.snapshots.current)
+	if err != nil {
+		return errors.Wrapf(err, "could not reopen persistent state in %s", o.PersistentState)
+	}
+	return nil
+}
+
+// write what overflow has set up next to the emulator state
+func (o *OverflowState) savePersistentState() error {
+	if o.PersistentState == "" {
+		return nil
+	}
+
+	persisted := o.persistedState()
+	persisted.Snapshots = o.snapshots.names
+	persisted.SnapshotCount = o.snapshots.count
+	persisted.CurrentSnapshot = o.snapshots.current
+	return persisted.write(o.PersistentState)
+}
+
+// what overflow has set up on top of the emulator state besides snapshots
+func (o *OverflowState) persistedState() *overflowPersistentState {
+	persisted := &overflowPersistentState{
+		ProposerKeyPools: map[string][]uint32{},
+	}
+	for address, pool := range o.ProposerKeyPools {
+		persisted.ProposerKeyPools[address] = pool.KeyIndexes
+	}
+	return persisted
+}
+
+func (p *overflowPersistentState) write(dir string) error {
+	content, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(dir, persistentStateFile), content, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "could not save persistent state in %s", dir)
+	}
+	return nil
+}
+
+// copy the database the emulator runs on into the file, after a snapshot has been restored that is the copy of the snapshot
+func (o *OverflowState) copyPersistentState(file string) error {
+	if o.store == nil || o.PersistentState == "" {
+		return fmt.Errorf("the emulator state can only be copied with WithPersistentState")
+	}
+	_, err := o.store.DB().Exec("VACUUM main INTO ?", file)
+	if err != nil {
+		return errors.Wrapf(err, "could not copy emulator state to %s", file)
+	}
+	return nil
+}
+
+// remove an emulator snapshot from the persistent state directory when it is no longer used.
+// The store cannot delete snapshots, so we check that it lists the snapshot before removing the file it keeps it in
+func (o *OverflowState) removeSnapshotFile(snapshot string) error {
+	if o.store == nil || o.PersistentState == "" || snapshot == "" {
+		return nil
+	}
+	snapshots, err := o.store.Snapshots()
+	if err != nil {
+		return errors.Wrapf(err, "could not list snapshots in %s", o.PersistentState)
+	}
+	if !slices.Contains(snapshots, snapshot) {
+		return fmt.Errorf("snapshot %s is not in the emulator store in %s", snapshot, o.PersistentState)
+	}
+
+	file := filepath.Join(o.PersistentState, persistentStateSnapshotPrefix+snapshot)
+	err = os.Remove(file)
+	if err != nil {
+		return errors.Wrapf(err, "could not remove snapshot %s, the emulator store does not keep it in %s", snapshot, file)
+	}
+	return nil
+}
+
+// the proposer keys added to the account the last time the persistent state was used
+func (p *overflowPersistentState) proposerKeys(o *OverflowState, accountName string, count int) []uint32 {
+	if p == nil {
+		return nil
+	}
+	account, err := o.accountByName(accountName)
+	if err != nil {
+		return nil
+	}
+	keyIndexes := p.ProposerKeyPools[account.Address.HexWithPrefix()]
+	if len(keyIndexes) != count+1 {
+		return nil
+	}
+	return keyIndexes
+}
+
+// store the state of the in memory emulator in the directory and continue from it if it already has state, so a long setup can be run once and reused, IE
+//
+//	o := Overflow(WithPersistentState(".overflow"))
+//	if !o.Reopened {
+//		// run the setup story
+//	}
+//
+// Accounts in flow.json that already exist are not created again and contracts are only updated.
+// What overflow sets up on top of the emulator, like proposer key pools and snapshots, is stored next to it
+func WithPersistentState(dir string) OverflowOption {
+	return func(o *OverflowBuilder) {
+		o.PersistentState = dir
+	}
+}
@@ -0,0 +1,94 @@
+package overflow
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistentState(t *testing.T) {
+	dir := t.TempDir()
+
+	start := func(t *testing.T) *OverflowState {
+		o, err := OverflowTesting(WithPersistentState(dir), WithProposerKeys("account", 2))
+		require.NoError(t, err)
+		require.NotNil(t, o)
+		return o
+	}
+
+	balance := func(t *testing.T, o *OverflowState) float64 {
+		value, err := o.Script(`access(all) fun main(address: Address): UFix64 { return getAccount(address).balance }`, WithArg("address", "first")).GetAsInterface()
+		require.NoError(t, err)
+		return value.(float64)
+	}
+
+	mint := func(t *testing.T, o *OverflowState, amount float64) {
+		o.Tx("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", amount),
+		).AssertSuccess(t)
+	}
+
+	o := start(t)
+	assert.False(t, o.Reopened)
+	mint(t, o, 10.0)
+	require.NoError(t, o.Snapshot("minted"))
+	minted := balance(t, o)
+	mint(t, o, 5.0)
+
+	block, err := o.GetLatestBlock(context.Background())
+	require.NoError(t, err)
+	keys, err := o.ListKeys(context.Background(), "account")
+	require.NoError(t, err)
+
+	t.Run("Reopen state", func(t *testing.T) {
+		reopened := start(t)
+		assert.True(t, reopened.Reopened)
+		assert.InDelta(t, minted+5.0, balance(t, reopened), 0.0001)
+
+		latest, err := reopened.GetLatestBlock(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, block.Height, latest.Height)
+
+		reopenedKeys, err := reopened.ListKeys(context.Background(), "account")
+		require.NoError(t, err)
+		assert.Len(t, reopenedKeys, len(keys))
+		assert.Len(t, reopened.ProposerKeyPools[reopened.Address("account")].KeyIndexes, 3)
+
+		assert.Equal(t, []string{"minted"}, reopened.ListSnapshots())
+		require.NoError(t, reopened.RestoreSnapshot("minted"))
+		assert.InDelta(t, minted, balance(t, reopened), 0.0001)
+		mint(t, reopened, 1.0)
+	})
+
+	t.Run("Reopen after restoring snapshot", func(t *testing.T) {
+		reopened := start(t)
+		assert.InDelta(t, minted+1.0, balance(t, reopened), 0.0001)
+	})
+
+	t.Run("Missing snapshot file", func(t *testing.T) {
+		reopened := start(t)
+		require.NoError(t, reopened.Snapshot("other"))
+		require.NoError(t, os.Remove(filepath.Join(dir, persistentStateSnapshotPrefix+reopened.snapshots.names["other"])))
+
+		err := reopened.Snapshot("other")
+		assert.ErrorContains(t, err, "is not in the emulator store in "+dir)
+	})
+
+	t.Run("Copy state without persistent state", func(t *testing.T) {
+		inMemory, err := OverflowTesting()
+		require.NoError(t, err)
+		err = inMemory.copyPersistentState(filepath.Join(t.TempDir(), persistentStateDatabase))
+		assert.ErrorContains(t, err, "the emulator state can only be copied with WithPersistentState")
+	})
+
+	t.Run("Only in memory", func(t *testing.T) {
+		_, err := OverflowTesting(WithNetwork("emulator"), WithPersistentState(dir))
+		assert.ErrorContains(t, err, "persistent state only works with the in memory emulator")
+	})
+}
@@ -0,0 +1,179 @@
+package overflow
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onflow/cadence/runtime"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// OverflowTestPool emulators cloned from the state after setup that tests take turns using, so tests can run in parallel each on their own emulator, IE
+//
+//	pool, _ := SetupTestPool(4, []OverflowOption{WithCoverageReport()}, func(o *OverflowState) error { ... })
+//	t.Cleanup(pool.Teardown)
+//	pool.Run(t, "buy", func(t *testing.T, o *OverflowState) { ... })
+//
+// The emulators are stored in a temporary directory with WithPersistentState and go back to the state after setup when a test is done
+type OverflowTestPool struct {
+	// the emulator the setup was run on, the clones start from its state
+	O *OverflowState
+	// every clone in the pool
+	Tests []*OverflowTest
+
+	dir  string
+	idle chan *OverflowTest
+}
+
+// SetupTestPool run the setup on an in memory emulator and clone its state into the given number of emulators
+func SetupTestPool(size int, opts []OverflowOption, setup func(o *OverflowState) error) (*OverflowTestPool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("a test pool needs at least one emulator but was asked for %d", size)
+	}
+
+	dir, err := os.MkdirTemp("", "overflow-pool")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create directory for test pool")
+	}
+	pool := &OverflowTestPool{
+		Tests: []*OverflowTest{},
+		dir:   dir,
+		idle:  make(chan *OverflowTest, size),
+	}
+
+	start := func(stateDir string) (*OverflowState, error) {
+		allOpts := []OverflowOption{WithNetwork("testing")}
+		allOpts = append(allOpts, opts...)
+		allOpts = append(allOpts, WithPersistentState(stateDir))
+		o := Overflow(allOpts...)
+		return o, o.Error
+	}
+
+	base, err := start(filepath.Join(dir, "setup"))
+	if err == nil {
+		err = setup(base)
+	}
+	if err == nil {
+		err = base.Error
+	}
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	pool.O = base
+
+	for i := 1; i <= size; i++ {
+		test, err := pool.clone(i, start)
+		if err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, errors.Wrapf(err, "could not clone emulator %d", i)
+		}
+		pool.Tests = append(pool.Tests, test)
+		pool.idle <- test
+	}
+	return pool, nil
+}
+
+// copy the database of the setup emulator into a directory of its own and reopen it there
+func (p *OverflowTestPool) clone(index int, start func(stateDir string) (*OverflowState, error)) (*OverflowTest, error) {
+	cloneDir := filepath.Join(p.dir, fmt.Sprintf("clone%d", index))
+	err := os.MkdirAll(cloneDir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := p.O.lockEmulatorLog()
+	err = p.O.copyPersistentState(filepath.Join(cloneDir, persistentStateDatabase))
+	unlock()
+	if err != nil {
+		return nil, err
+	}
+	err = p.O.persistedState().write(cloneDir)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := start(cloneDir)
+	if err != nil {
+		return nil, err
+	}
+	block, err := o.GetLatestBlock(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &OverflowTest{O: o, height: block.Height}, nil
+}
+
+// Run run the test in parallel with the other tests in the pool on an emulator of its own, it waits for an emulator if they are all in use
+func (p *OverflowTestPool) Run(t *testing.T, name string, f func(t *testing.T, o *OverflowState)) {
+	t.Helper()
+	t.Run(name, func(t *testing.T) {
+		t.Parallel()
+		test := <-p.idle
+		defer func() {
+			p.idle <- test
+		}()
+
+		f(t, test.O)
+
+		// the next test starts from the state after setup again
+		err := test.Reset()
+		require.NoError(t, err)
+		err = test.O.ResetBlockTime()
+		require.NoError(t, err)
+	})
+}
+
+// CoverageReport the coverage of the setup and all tests in the pool together, nil if the pool does not collect coverage
+func (p *OverflowTestPool) CoverageReport() *runtime.CoverageReport {
+	if p.O.GetCoverageReport() == nil {
+		return nil
+	}
+
+	report := runtime.NewCoverageReport()
+	mergeCoverageReport(report, p.O.GetCoverageReport())
+	for _, test := range p.Tests {
+		mergeCoverageReport(report, test.O.GetCoverageReport())
+	}
+	return report
+}
+
+// Teardown write the coverage report of the pool and remove the emulators.
+// Register it with t.Cleanup, a deferred call runs before the parallel tests started with Run
+func (p *OverflowTestPool) Teardown() {
+	defer os.RemoveAll(p.dir)
+
+	report := p.CoverageReport()
+	if report == nil {
+		return
+	}
+	writeCoverageReport(report)
+}
+
+// add the hits in the other report to the report, cadence replaces the coverage of a location when merging
+func mergeCoverageReport(report *runtime.CoverageReport, other *runtime.CoverageReport) {
+	if other == nil {
+		return
+	}
+
+	for location, coverage := range other.Coverage {
+		existing, ok := report.Coverage[location]
+		if !ok {
+			existing = &runtime.LocationCoverage{LineHits: map[int]int{}, Statements: coverage.Statements}
+			report.Coverage[location] = existing
+		}
+		for line, hits := range coverage.LineHits {
+			existing.LineHits[line] += hits
+		}
+	}
+	for location := range other.Locations {
+		report.Locations[location] = struct{}{}
+	}
+	for location := range other.ExcludedLocations {
+		report.ExcludedLocations[location] = struct{}{}
+	}
+}
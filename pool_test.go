@@ -0,0 +1,82 @@
+package overflow
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestPool(t *testing.T) {
+	balance := func(t *testing.T, o *OverflowState) float64 {
+		value, err := o.Script(`access(all) fun main(address: Address): UFix64 { return getAccount(address).balance }`, WithArg("address", "first")).GetAsInterface()
+		require.NoError(t, err)
+		return value.(float64)
+	}
+
+	mint := func(t *testing.T, o *OverflowState, amount float64) {
+		o.Tx("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", amount),
+		).AssertSuccess(t)
+	}
+
+	pool, err := SetupTestPool(2, []OverflowOption{WithCoverageReport()}, func(o *OverflowState) error {
+		mint(t, o, 10.0)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, pool.Tests, 2)
+	setup := balance(t, pool.O)
+
+	t.Run("Parallel tests", func(t *testing.T) {
+		for i := 1; i <= 4; i++ {
+			amount := float64(i)
+			pool.Run(t, fmt.Sprintf("Mint %v", amount), func(t *testing.T, o *OverflowState) {
+				assert.NotSame(t, pool.O, o)
+				assert.InDelta(t, setup, balance(t, o), 0.0001)
+				mint(t, o, amount)
+				require.NoError(t, o.AdvanceTime(time.Hour))
+				assert.InDelta(t, setup+amount, balance(t, o), 0.0001)
+			})
+		}
+	})
+
+	t.Run("Setup emulator is untouched", func(t *testing.T) {
+		assert.InDelta(t, setup, balance(t, pool.O), 0.0001)
+	})
+
+	t.Run("Coverage is merged", func(t *testing.T) {
+		report := pool.CoverageReport()
+		require.NotNil(t, report)
+		hits := pool.O.GetCoverageReport().Hits()
+		for _, test := range pool.Tests {
+			assert.Greater(t, report.Hits(), test.O.GetCoverageReport().Hits())
+		}
+		assert.GreaterOrEqual(t, report.Hits(), hits)
+	})
+
+	pool.Teardown()
+	assert.NoDirExists(t, pool.dir)
+
+	var cleaned *OverflowTestPool
+	t.Run("Teardown after parallel tests", func(t *testing.T) {
+		cleaned, err = SetupTestPool(1, nil, func(o *OverflowState) error { return nil })
+		require.NoError(t, err)
+		t.Cleanup(cleaned.Teardown)
+
+		cleaned.Run(t, "Mint", func(t *testing.T, o *OverflowState) {
+			assert.DirExists(t, cleaned.dir)
+			mint(t, o, 1.0)
+		})
+	})
+	assert.NoDirExists(t, cleaned.dir)
+
+	t.Run("Needs an emulator", func(t *testing.T) {
+		_, err := SetupTestPool(0, nil, func(o *OverflowState) error { return nil })
+		assert.ErrorContains(t, err, "a test pool needs at least one emulator")
+	})
+}
@@ -0,0 +1,224 @@
+package overflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flowkit/v2/accounts"
+	"github.com/pkg/errors"
+)
+
+// OverflowProposerKeyPool a pool of keys on a single account that are used as proposal keys in turn.
+// Flow tracks a sequence number for every key, so transactions proposed with the same key cannot be sent concurrently,
+// the pool hands out one key per transaction being built so that many transactions from the same account can be sent from goroutines
+type OverflowProposerKeyPool struct {
+	Account    *accounts.Account
+	KeyIndexes []uint32
+
+	available chan uint32
+
+	mutex sync.Mutex
+	// the next sequence number for every key we have sent a transaction with
+	sequenceNumbers map[uint32]uint64
+}
+
+// NewProposerKeyPool create a pool of the given key indexes on the account, all keys must use the same private key as the account
+func NewProposerKeyPool(account *accounts.Account, keyIndexes ...uint32) *OverflowProposerKeyPool {
+	pool := &OverflowProposerKeyPool{
+		Account:         account,
+		KeyIndexes:      keyIndexes,
+		available:       make(chan uint32, len(keyIndexes)),
+		sequenceNumbers: map[uint32]uint64{},
+	}
+	for _, index := range keyIndexes {
+		pool.available <- index
+	}
+	return pool
+}
+
+// a key that is checked out from a pool while a transaction is built and sent
+type proposerKeyLease struct {
+	pool           *OverflowProposerKeyPool
+	index          uint32
+	sequenceNumber uint64
+}
+
+// take a key from the pool, waits until one is available
+func (p *OverflowProposerKeyPool) acquire(ctx context.Context) (*proposerKeyLease, error) {
+	select {
+	case index := <-p.available:
+		return &proposerKeyLease{pool: p, index: index}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// the account with the key of the lease, used to sign with the leased key
+func (l *proposerKeyLease) account() *accounts.Account {
+	return &accounts.Account{
+		Name:    l.pool.Account.Name,
+		Address: l.pool.Account.Address,
+		Key:     proposerPoolKey{Key: l.pool.Account.Key, index: l.index},
+	}
+}
+
+// decide the sequence number to use, the one on chain lags behind if we have transactions that are not sealed yet
+func (l *proposerKeyLease) useSequenceNumber(onChain uint64) uint64 {
+	l.pool.mutex.Lock()
+	defer l.pool.mutex.Unlock()
+
+	l.sequenceNumber = onChain
+	if known, ok := l.pool.sequenceNumbers[l.index]; ok && known > onChain {
+		l.sequenceNumber = known
+	}
+	return l.sequenceNumber
+}
+
+// give the key back to the pool, if the transaction was sent the sequence number is used up
+func (l *proposerKeyLease) release(sent bool) {
+	if l == nil {
+		return
+	}
+	if sent {
+		l.pool.mutex.Lock()
+		l.pool.sequenceNumbers[l.index] = l.sequenceNumber + 1
+		l.pool.mutex.Unlock()
+	}
+	l.pool.available <- l.index
+}
+
+// stop tracking the sequence numbers of all keys in all pools, after the emulator goes back to an earlier state the chain is behind them
+func (o *OverflowState) forgetAllSequenceNumbers() {
+	for _, pool := range o.ProposerKeyPools {
+		pool.mutex.Lock()
+		pool.sequenceNumbers = map[uint32]uint64{}
+		pool.mutex.Unlock()
+	}
+}
+
+// stop tracking the sequence number of the key after the transaction proposed with it failed, expired or got lost, unless the key has been used again since.
+// The chain might not have counted the transaction so the next one takes the sequence number from chain
+func (o *OverflowState) dropSequenceNumber(key flow.ProposalKey) {
+	pool, ok := o.ProposerKeyPools[key.Address.HexWithPrefix()]
+	if !ok {
+		return
+	}
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	if known, ok := pool.sequenceNumbers[key.KeyIndex]; ok && known == key.SequenceNumber+1 {
+		delete(pool.sequenceNumbers, key.KeyIndex)
+	}
+}
+
+// a transaction that has been built but not sent yet, holding its proposer key and the emulator
+type pendingSend struct {
+	lease       *proposerKeyLease
+	unlock      func()
+	reservation *sponsorReservation
+
+	// set if the transaction goes into a block that is committed manually
+	block       *overflowPendingBlock
+	proposalKey flow.ProposalKey
+}
+
+// release the proposer key and the emulator, if the transaction was sent the sequence number of the key is used up.
+// A transaction that was not sent does not pay a fee so the reservation of its sponsor is given back
+func (p *pendingSend) done(sent bool) {
+	p.lease.release(sent)
+	if !sent {
+		p.reservation.settle(nil)
+	}
+	if sent && p.block != nil {
+		p.block.useSequenceNumber(p.proposalKey)
+	}
+	p.unlock()
+}
+
+// an account key that signs with another key index on the same account
+type proposerPoolKey struct {
+	accounts.Key
+	index uint32
+}
+
+func (k proposerPoolKey) Index() uint32 {
+	return k.index
+}
+
+// lease a key if there is a pool for the proposer
+func (o *OverflowState) leaseProposerKey(ctx context.Context, proposer *accounts.Account) (*proposerKeyLease, error) {
+	pool, ok := o.ProposerKeyPools[proposer.Address.HexWithPrefix()]
+	if !ok {
+		return nil, nil
+	}
+	lease, err := pool.acquire(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get a proposer key for %s", proposer.Address.HexWithPrefix())
+	}
+	return lease, nil
+}
+
+// AddProposerKeyPool use the given key indexes on the account as proposal keys in turn, the keys must already exist on the account with the same private key
+func (o *OverflowState) AddProposerKeyPool(accountName string, keyIndexes ...uint32) (*OverflowProposerKeyPool, error) {
+	if len(keyIndexes) == 0 {
+		return nil, fmt.Errorf("a proposer key pool needs at least one key index")
+	}
+	account, err := o.accountByName(accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := NewProposerKeyPool(account, keyIndexes...)
+	o.ProposerKeyPools[account.Address.HexWithPrefix()] = pool
+	return pool, nil
+}
+
+// AddProposerKeys add count new keys with the same public key as the account has in flow.json and use all of them as proposal keys
+func (o *OverflowState) AddProposerKeys(ctx context.Context, accountName string, count int) (*OverflowProposerKeyPool, error) {
+	account, err := o.accountByName(accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := account.Key.PrivateKey()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get private key for %s", accountName)
+	}
+
+	err = o.addKeys(ctx, account, (*privateKey).PublicKey(), account.Key.HashAlgo(), 1000, count)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not add proposer keys to %s", accountName)
+	}
+
+	keys, err := o.ListKeys(ctx, accountName)
+	if err != nil {
+		return nil, err
+	}
+
+	keyIndexes := []uint32{account.Key.Index()}
+	for _, key := range keys[len(keys)-count:] {
+		keyIndexes = append(keyIndexes, key.Index)
+	}
+	return o.AddProposerKeyPool(accountName, keyIndexes...)
+}
+
+// Use the given key indexes on the account as a pool of proposal keys so transactions from it can be sent concurrently
+func WithProposerKeyPool(accountName string, keyIndexes ...uint32) OverflowOption {
+	return func(o *OverflowBuilder) {
+		if o.ProposerKeyPools == nil {
+			o.ProposerKeyPools = map[string][]uint32{}
+		}
+		o.ProposerKeyPools[accountName] = keyIndexes
+	}
+}
+
+// Add count keys to the account when starting and use them as a pool of proposal keys, mostly useful for the emulator
+func WithProposerKeys(accountName string, count int) OverflowOption {
+	return func(o *OverflowBuilder) {
+		if o.ProposerKeyCounts == nil {
+			o.ProposerKeyCounts = map[string]int{}
+		}
+		o.ProposerKeyCounts[accountName] = count
+	}
+}
@@ -0,0 +1,139 @@
+package overflow
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProposerKeyPool(t *testing.T) {
+	o, err := OverflowTesting(WithProposerKeys("account", 4))
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	pool := o.ProposerKeyPools[o.Address("account")]
+	require.NotNil(t, pool)
+	assert.Equal(t, []uint32{0, 1, 2, 3, 4}, pool.KeyIndexes)
+
+	t.Run("Send transactions concurrently", func(t *testing.T) {
+		var wg sync.WaitGroup
+		results := make([]*OverflowResult, 10)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = o.Tx("mint_tokens",
+					WithSignerServiceAccount(),
+					WithArg("recipient", "first"),
+					WithArg("amount", 1.0),
+				)
+			}(i)
+		}
+		wg.Wait()
+
+		keys := map[uint32]bool{}
+		for _, result := range results {
+			result.AssertSuccess(t).AssertEvent(t, "TokensMinted", map[string]interface{}{"amount": 1.0})
+			keys[result.Transaction.ProposalKey.KeyIndex] = true
+		}
+		assert.Greater(t, len(keys), 1)
+	})
+
+	t.Run("Sequence numbers are tracked per key", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			o.Tx("mint_tokens",
+				WithSignerServiceAccount(),
+				WithArg("recipient", "first"),
+				WithArg("amount", 1.0),
+			).AssertSuccess(t)
+		}
+	})
+
+	t.Run("Emulator log is left alone on other networks", func(t *testing.T) {
+		network := *o
+		network.EmulatorGatway = nil
+		o.Log.WriteString("{\"msg\":\"kept\"}\n")
+		defer o.Log.Reset()
+
+		unlock := network.lockEmulatorLog()
+		unlock()
+		log, err := network.readLog()
+		require.NoError(t, err)
+		assert.Empty(t, log)
+		assert.Contains(t, o.Log.String(), "kept")
+	})
+
+	t.Run("Pool with unknown account", func(t *testing.T) {
+		_, err := o.AddProposerKeyPool("foobar", 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("Pool without keys", func(t *testing.T) {
+		_, err := o.AddProposerKeyPool("account")
+		assert.ErrorContains(t, err, "a proposer key pool needs at least one key index")
+	})
+}
+
+func TestProposerKeyPoolSequenceNumbers(t *testing.T) {
+	o, err := OverflowTesting(WithProposerKeyPool("account", 0))
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	pool := o.ProposerKeyPools[o.Address("account")]
+	require.NotNil(t, pool)
+	known := func() (uint64, bool) {
+		pool.mutex.Lock()
+		defer pool.mutex.Unlock()
+		sequenceNumber, ok := pool.sequenceNumbers[0]
+		return sequenceNumber, ok
+	}
+
+	t.Run("Track sequence number of successful transaction", func(t *testing.T) {
+		result := o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0))
+		result.AssertSuccess(t)
+		sequenceNumber, ok := known()
+		require.True(t, ok)
+		assert.Equal(t, result.Transaction.ProposalKey.SequenceNumber+1, sequenceNumber)
+	})
+
+	t.Run("Drop sequence number of failing transaction", func(t *testing.T) {
+		result := o.Tx(`transaction { prepare(signer: &Account) { panic("oops") } }`, WithSignerServiceAccount())
+		result.AssertFailure(t, "oops")
+		_, ok := known()
+		assert.False(t, ok)
+
+		o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+	})
+
+	t.Run("Keep sequence number if key was used again", func(t *testing.T) {
+		key := flow.ProposalKey{Address: pool.Account.Address, KeyIndex: 0, SequenceNumber: 10}
+		pool.mutex.Lock()
+		pool.sequenceNumbers[0] = 12
+		pool.mutex.Unlock()
+
+		o.dropSequenceNumber(key)
+		sequenceNumber, ok := known()
+		require.True(t, ok)
+		assert.Equal(t, uint64(12), sequenceNumber)
+
+		key.SequenceNumber = 11
+		o.dropSequenceNumber(key)
+		_, ok = known()
+		assert.False(t, ok)
+	})
+}
+
+func TestProposerKeyPoolReset(t *testing.T) {
+	ot, err := SetupTest([]OverflowOption{WithProposerKeyPool("account", 0)}, func(o *OverflowState) error { return nil })
+	require.NoError(t, err)
+
+	mint := func(t *testing.T) {
+		ot.O.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+	}
+
+	ot.Run(t, "Send before reset", mint)
+	ot.Run(t, "Send after reset", mint)
+}
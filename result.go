@@ -65,6 +65,24 @@ type OverflowResult struct {
 	Arguments        CadenceArguments
 	UnderflowOptions underflow.Options
 	DeclarationInfo  OverflowDeclarationInfo
+
+	// how many times the transaction was sent and the errors of the attempts that were retried, see WithRetry
+	Attempts      int
+	AttemptErrors []error
+
+	// the state change of the transaction was thrown away or it was never sent, see WithDryRun
+	DryRun bool
+
+	// the transaction was sent but fetching its result failed, so it may have run and must not be sent again
+	resultUnknown bool
+
+	// a sponsor was added as payer of the transaction, so the fee goes in its ledger
+	sponsored bool
+	// the fee held against the spending limit of the sponsor until the transaction has a result
+	sponsorReservation *sponsorReservation
+
+	// the transaction is in the pending block and the result is filled in when the block is committed, see CommitBlock
+	Pending bool
 }
 
 func (o OverflowResult) PrintArguments(t *testing.T) {
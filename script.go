@@ -71,7 +71,8 @@ func (fbi *OverflowInteractionBuilder) runScript() *OverflowScriptResult {
 
 	filePath := fmt.Sprintf("%s/%s.cdc", fbi.BasePath, fbi.FileName)
 
-	o.Log.Reset()
+	unlock := o.lockEmulatorLog()
+	defer unlock()
 
 	script := flowkit.Script{
 		Code:     fbi.TransactionCode,
@@ -84,7 +85,16 @@ func (fbi *OverflowInteractionBuilder) runScript() *OverflowScriptResult {
 			Latest: true,
 		}
 	}
-	result, err := o.Flowkit.ExecuteScript(fbi.Ctx, script, *sc)
+	var result cadence.Value
+	var err error
+	if sc.Latest {
+		result, err = o.Flowkit.ExecuteScript(fbi.Ctx, script, *sc)
+	} else {
+		// historical scripts might have to run against an archive node
+		result, err = withArchiveFallback(o, func(fk *flowkit.Flowkit) (cadence.Value, error) {
+			return fk.ExecuteScript(fbi.Ctx, script, *sc)
+		})
+	}
 	osc.Result = result
 	osc.Output = underflow.CadenceValueToInterfaceWithOption(result, fbi.Overflow.UnderflowOptions)
 	if err != nil {
@@ -92,6 +102,11 @@ func (fbi *OverflowInteractionBuilder) runScript() *OverflowScriptResult {
 	}
 	//}
 
+	// only the in memory emulator has a log
+	if o.EmulatorGatway == nil {
+		return osc
+	}
+
 	var logMessage []OverflowEmulatorLogMessage
 	dec := json.NewDecoder(o.Log)
 	for {
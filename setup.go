@@ -14,13 +14,18 @@ import (
 	"io/fs"
 	"os"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/bjartek/underflow"
 	"github.com/onflow/cadence/runtime"
 	"github.com/onflow/flixkit-go/v2/flixkit"
 	"github.com/onflow/flow-emulator/emulator"
+	"github.com/onflow/flow-emulator/storage/sqlite"
 	grpcAccess "github.com/onflow/flow-go-sdk/access/grpc"
+	flowgo "github.com/onflow/flow-go/model/flow"
 	"github.com/onflow/flowkit/v2"
+	"github.com/onflow/flowkit/v2/accounts"
 	"github.com/onflow/flowkit/v2/config"
 	"github.com/onflow/flowkit/v2/gateway"
 	"github.com/onflow/flowkit/v2/output"
@@ -81,6 +86,7 @@ var defaultOverflowBuilder = OverflowBuilder{
 	TransactionFees:                     true,
 	Coverage:                            nil,
 	UnderflowOptions:                    underflow.Options{},
+	BlockStatus:                         BlockStatusSealed,
 }
 
 // OverflowBuilder is the struct used to gather up configuration when building an overflow instance
@@ -93,6 +99,16 @@ type OverflowBuilder struct {
 	GlobalEventFilter                   OverflowEventFilter
 	Path                                string
 	NetworkHost                         string
+	ArchiveNodeHost                     string
+	BlockStatus                         OverflowBlockStatus
+	ConfirmationDepth                   uint64
+	ProposerKeyPools                    map[string][]uint32
+	ProposerKeyCounts                   map[string]int
+	SignerAccounts                      map[string]OverflowSignerAccount
+	RetryBackoff                        time.Duration
+	RetryMax                            int
+	Sponsor                             string
+	SponsorLimits                       map[string]float64
 	Network                             string
 	ScriptFolderName                    string
 	ServiceSuffix                       string
@@ -106,6 +122,9 @@ type OverflowBuilder struct {
 	UnderflowOptions                    underflow.Options
 	DeployContracts                     bool
 	InMemory                            bool
+	ManualBlockCommit                   bool
+	PersistentState                     string
+	ForkedState                         string
 	InitializeAccounts                  bool
 	StopOnError                         bool
 	TransactionFees                     bool
@@ -154,6 +173,16 @@ func (o *OverflowBuilder) StartResult() *OverflowState {
 		LogLevel:                            o.LogLevel,
 		CoverageReport:                      o.Coverage,
 		UnderflowOptions:                    o.UnderflowOptions,
+		BlockStatus:                         o.BlockStatus,
+		ConfirmationDepth:                   o.ConfirmationDepth,
+		RetryMax:                            o.RetryMax,
+		RetryBackoff:                        o.RetryBackoff,
+		ProposerKeyPools:                    map[string]*OverflowProposerKeyPool{},
+		Sponsors:                            map[string]*OverflowSponsor{},
+		snapshots:                           &overflowSnapshots{names: map[string]string{}},
+		clock:                               &overflowClock{},
+		SignerAccounts:                      map[string]*accounts.Account{},
+		logMutex:                            &sync.Mutex{},
 	}
 
 	loader := o.ReaderWriter
@@ -193,7 +222,18 @@ func (o *OverflowBuilder) StartResult() *OverflowState {
 			return address.HexWithPrefix(), nil
 		}
 	}
-	network, err := state.Networks().ByName(o.Network)
+	networkName := o.Network
+	var fork *OverflowNetworkExport
+	if o.ForkedState != "" {
+		fork, err = readNetworkExport(o.ForkedState)
+		if err != nil {
+			overflow.Error = err
+			return overflow
+		}
+		overflow.Fork = fork
+		networkName = fork.Network
+	}
+	network, err := state.Networks().ByName(networkName)
 	if err != nil {
 		overflow.Error = err
 		return overflow
@@ -203,11 +243,29 @@ func (o *OverflowBuilder) StartResult() *OverflowState {
 	}
 	overflow.Network = *network
 
+	for name, signerAccount := range o.SignerAccounts {
+		_, err := overflow.AddSignerAccount(name, signerAccount.Address, signerAccount.KeyIndex, signerAccount.SigAlgo, signerAccount.HashAlgo, signerAccount.Signer)
+		if err != nil {
+			overflow.Error = err
+			return overflow
+		}
+	}
+
 	logger := output.NewStdoutLogger(o.LogLevel)
 	overflow.Logger = logger
 	var memlog bytes.Buffer
 	overflow.Log = &memlog
 
+	if o.PersistentState != "" && !o.InMemory {
+		overflow.Error = fmt.Errorf("persistent state only works with the in memory emulator")
+		return overflow
+	}
+	if fork != nil && !o.InMemory {
+		overflow.Error = fmt.Errorf("forked state only works with the in memory emulator")
+		return overflow
+	}
+
+	var persisted *overflowPersistentState
 	if o.InMemory {
 		acc, _ := state.EmulatorServiceAccount()
 
@@ -224,6 +282,34 @@ func (o *OverflowBuilder) StartResult() *OverflowState {
 			emulatorOptions = append(emulatorOptions, emulator.WithTransactionFeesEnabled(true), emulator.WithCoverageReport(o.Coverage))
 		}
 
+		var store *sqlite.Store
+		if o.PersistentState != "" {
+			store, persisted, err = openPersistentState(o.PersistentState)
+			if err != nil {
+				overflow.Error = err
+				return overflow
+			}
+			overflow.PersistentState = o.PersistentState
+			overflow.Reopened = persisted != nil
+		}
+		if store == nil {
+			// the emulator would create the same store itself, we create it so we can reach the database it runs on
+			store, err = sqlite.New(sqlite.InMemory)
+			if err != nil {
+				overflow.Error = errors.Wrap(err, "could not create emulator store")
+				return overflow
+			}
+		}
+		if fork != nil {
+			// mainnet accounts do not have our keys so the emulator does not check signatures
+			emulatorOptions = append(emulatorOptions,
+				emulator.WithChainID(flowgo.ChainID(fork.ChainID)),
+				emulator.WithTransactionValidationEnabled(false),
+			)
+		}
+		emulatorOptions = append(emulatorOptions, emulator.WithStore(store))
+		overflow.store = store
+
 		emulatorOptions = append(emulatorOptions, o.EmulatorOptions...)
 
 		pk, _ := acc.Key.PrivateKey()
@@ -232,12 +318,29 @@ func (o *OverflowBuilder) StartResult() *OverflowState {
 			SigAlgo:   acc.Key.SigAlgo(),
 			HashAlgo:  acc.Key.HashAlgo(),
 		}
-		gw := gateway.NewEmulatorGatewayWithOpts(emulatorKey,
-			gateway.WithLogger(&emulatorLogger),
-			gateway.WithEmulatorOptions(emulatorOptions...),
-		)
+		gw, err := newEmulatorGateway(emulatorKey, &emulatorLogger, emulatorOptions...)
+		if err != nil {
+			overflow.Error = errors.Wrap(err, "could not start emulator")
+			return overflow
+		}
 
 		overflow.EmulatorGatway = gw
+		overflow.Emulator = gw.emulator
+		overflow.Emulator.SetClock(overflow.clock)
+		if persisted != nil {
+			err := overflow.reopenPersistentState(persisted)
+			if err != nil {
+				overflow.Error = err
+				return overflow
+			}
+		}
+		if fork != nil {
+			err := overflow.startFork(o.Ctx, store, fork, persisted == nil)
+			if err != nil {
+				overflow.Error = err
+				return overflow
+			}
+		}
 		overflow.Flowkit = flowkit.NewFlowkit(state, *network, gw, logger)
 	} else {
 		clientOpts := grpcAccess.WithGRPCDialOptions(o.GrpcDialOptions...)
@@ -247,6 +350,25 @@ func (o *OverflowBuilder) StartResult() *OverflowState {
 			return overflow
 		}
 		overflow.Flowkit = flowkit.NewFlowkit(state, *network, gw, logger)
+
+		accessClient, err := grpcAccess.NewClient(network.Host, clientOpts)
+		if err != nil {
+			overflow.Error = errors.Wrapf(err, "could not connect to access node %s", network.Host)
+			return overflow
+		}
+		overflow.AccessClient = accessClient
+	}
+
+	if o.ArchiveNodeHost != "" {
+		archiveNetwork := *network
+		archiveNetwork.Host = o.ArchiveNodeHost
+		clientOpts := grpcAccess.WithGRPCDialOptions(o.GrpcDialOptions...)
+		gw, err := gateway.NewGrpcGateway(archiveNetwork, clientOpts)
+		if err != nil {
+			overflow.Error = errors.Wrap(err, "could not connect to archive node")
+			return overflow
+		}
+		overflow.ArchiveFlowkit = flowkit.NewFlowkit(state, archiveNetwork, gw, logger)
 	}
 
 	if o.InitializeAccounts {
@@ -263,6 +385,46 @@ func (o *OverflowBuilder) StartResult() *OverflowState {
 			return overflow
 		}
 	}
+	for name, keyIndexes := range o.ProposerKeyPools {
+		_, err := overflow.AddProposerKeyPool(name, keyIndexes...)
+		if err != nil {
+			overflow.Error = err
+			return overflow
+		}
+	}
+	for name, count := range o.ProposerKeyCounts {
+		var err error
+		if keyIndexes := persisted.proposerKeys(overflow, name, count); keyIndexes != nil {
+			_, err = overflow.AddProposerKeyPool(name, keyIndexes...)
+		} else {
+			_, err = overflow.AddProposerKeys(o.Ctx, name, count)
+		}
+		if err != nil {
+			overflow.Error = err
+			return overflow
+		}
+	}
+	for name, spendingLimit := range o.SponsorLimits {
+		_, err := overflow.AddSponsor(name, spendingLimit)
+		if err != nil {
+			overflow.Error = err
+			return overflow
+		}
+	}
+	// accounts and contracts are set up by the service account before the sponsor takes over
+	overflow.Sponsor = o.Sponsor
+	if o.ManualBlockCommit {
+		err := overflow.SetManualBlockCommit(true)
+		if err != nil {
+			overflow.Error = err
+			return overflow
+		}
+	}
+	err = overflow.savePersistentState()
+	if err != nil {
+		overflow.Error = err
+		return overflow
+	}
 	return overflow
 }
 
@@ -529,6 +691,27 @@ func WithNetworkHost(host string) OverflowOption {
 	}
 }
 
+// Set the host of an archive node, queries for historical data that is pruned from the access node will be retried against it
+func WithArchiveNode(host string) OverflowOption {
+	return func(o *OverflowBuilder) {
+		o.ArchiveNodeHost = host
+	}
+}
+
+// Set the status a block must have to be returned as the latest block, default is BlockStatusSealed
+func WithBlockStatus(status OverflowBlockStatus) OverflowOption {
+	return func(o *OverflowBuilder) {
+		o.BlockStatus = status
+	}
+}
+
+// Hold back this many blocks from the latest block when fetching events, subscribing or crawling so that only confirmed blocks are used
+func WithConfirmationDepth(depth uint64) OverflowOption {
+	return func(o *OverflowBuilder) {
+		o.ConfirmationDepth = depth
+	}
+}
+
 func WithUnderflowOptions(opt underflow.Options) OverflowOption {
 	return func(o *OverflowBuilder) {
 		o.UnderflowOptions = opt
@@ -114,6 +114,17 @@ func TestOverflowv3(t *testing.T) {
 		assert.Equal(t, "tx", b.TransactionFolderName)
 	})
 
+	t.Run("WithArchiveNode", func(t *testing.T) {
+		b := Apply(WithArchiveNode("archive.mainnet.nodes.onflow.org:9000"))
+		assert.Equal(t, "archive.mainnet.nodes.onflow.org:9000", b.ArchiveNodeHost)
+	})
+
+	t.Run("WithBlockStatus and WithConfirmationDepth", func(t *testing.T) {
+		b := Apply(WithBlockStatus(BlockStatusFinalized), WithConfirmationDepth(10))
+		assert.Equal(t, BlockStatusFinalized, b.BlockStatus)
+		assert.Equal(t, uint64(10), b.ConfirmationDepth)
+	})
+
 	t.Run("Overflow panics", func(t *testing.T) {
 		assert.Panics(t, func() {
 			Overflow(WithFlowConfig("nonexistant.json"))
@@ -15,12 +15,12 @@ func (o *OverflowState) SignUserMessage(account string, message string) (string,
 		return "", err
 	}
 
-	signer, err := a.Key.Signer(context.Background())
+	signer, err := o.signerFor(context.Background(), a)
 	if err != nil {
 		return "", err
 	}
 
-	signature, err := flow.SignUserMessage(signer, []byte(message))
+	signature, err := signer.Sign(context.Background(), append(flow.UserDomainTag[:], []byte(message)...))
 	if err != nil {
 		return "", err
 	}
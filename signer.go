@@ -0,0 +1,252 @@
+package overflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"github.com/onflow/flowkit/v2/accounts"
+	"github.com/onflow/flowkit/v2/config"
+	"github.com/pkg/errors"
+)
+
+// Signer signs a message with the key of an account, the message has the domain tag prepended and is hashed by the signer with the hash algorithm of the key
+type Signer interface {
+	Sign(ctx context.Context, message []byte) ([]byte, error)
+}
+
+// CallbackSigner sign messages by calling a function
+type CallbackSigner func(ctx context.Context, message []byte) ([]byte, error)
+
+func (f CallbackSigner) Sign(ctx context.Context, message []byte) ([]byte, error) {
+	return f(ctx, message)
+}
+
+// LocalSigner sign messages with a private key in memory
+type LocalSigner struct {
+	signer crypto.InMemorySigner
+}
+
+// NewLocalSigner create a signer for the private key that hashes messages with the hash algorithm
+func NewLocalSigner(privateKey crypto.PrivateKey, hashAlgorithm crypto.HashAlgorithm) (*LocalSigner, error) {
+	signer, err := crypto.NewInMemorySigner(privateKey, hashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalSigner{signer: signer}, nil
+}
+
+func (s *LocalSigner) Sign(_ context.Context, message []byte) ([]byte, error) {
+	return s.signer.Sign(message)
+}
+
+// HTTPSigner sign messages by posting them to a signing service.
+// The service gets a json body with the hex encoded message, IE {"message":"..."}, and must respond with the hex encoded signature, IE {"signature":"..."}
+type HTTPSigner struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// HTTPSignRequest the body posted to a signing service
+type HTTPSignRequest struct {
+	Message string `json:"message"`
+}
+
+// HTTPSignResponse the body a signing service responds with
+type HTTPSignResponse struct {
+	Signature string `json:"signature"`
+}
+
+// how long a signing service gets to respond before signing fails, set Client to use another timeout
+const httpSignerTimeout = 30 * time.Second
+
+// NewHTTPSigner create a signer that posts messages to the url
+func NewHTTPSigner(url string) *HTTPSigner {
+	return &HTTPSigner{
+		URL:     url,
+		Headers: map[string]string{},
+		Client:  &http.Client{Timeout: httpSignerTimeout},
+	}
+}
+
+// set a header on every request, IE for authorization
+func (s *HTTPSigner) WithHeader(name string, value string) *HTTPSigner {
+	s.Headers[name] = value
+	return s
+}
+
+func (s *HTTPSigner) Sign(ctx context.Context, message []byte) ([]byte, error) {
+	body, err := json.Marshal(HTTPSignRequest{Message: hex.EncodeToString(message)})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not sign with %s", s.URL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("could not sign with %s status %d %s", s.URL, resp.StatusCode, resp.Status)
+	}
+
+	var signResponse HTTPSignResponse
+	err = json.NewDecoder(resp.Body).Decode(&signResponse)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse response from %s", s.URL)
+	}
+
+	signature, err := hex.DecodeString(signResponse.Signature)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not decode signature from %s", s.URL)
+	}
+	return signature, nil
+}
+
+// OverflowSignerAccount an account that is signed for by a signer, see WithSignerAccount
+type OverflowSignerAccount struct {
+	Address  string
+	KeyIndex uint32
+	// the algorithms of the account key the signer signs for
+	SigAlgo  crypto.SignatureAlgorithm
+	HashAlgo crypto.HashAlgorithm
+	Signer   Signer
+}
+
+// the key type of accounts signed for by a Signer
+const signerKeyType config.KeyType = "signer"
+
+// an account key that signs with a Signer, so it can be used everywhere flowkit accounts are
+type signerKey struct {
+	signer   Signer
+	index    uint32
+	sigAlgo  crypto.SignatureAlgorithm
+	hashAlgo crypto.HashAlgorithm
+}
+
+var _ accounts.Key = &signerKey{}
+
+func (k *signerKey) Type() config.KeyType {
+	return signerKeyType
+}
+
+func (k *signerKey) Index() uint32 {
+	return k.index
+}
+
+func (k *signerKey) SigAlgo() crypto.SignatureAlgorithm {
+	return k.sigAlgo
+}
+
+func (k *signerKey) HashAlgo() crypto.HashAlgorithm {
+	return k.hashAlgo
+}
+
+func (k *signerKey) Signer(ctx context.Context) (crypto.Signer, error) {
+	return &contextSigner{ctx: ctx, signer: k.signer}, nil
+}
+
+func (k *signerKey) ToConfig() config.AccountKey {
+	return config.AccountKey{
+		Type:     signerKeyType,
+		Index:    k.index,
+		SigAlgo:  k.sigAlgo,
+		HashAlgo: k.hashAlgo,
+	}
+}
+
+func (k *signerKey) Validate() error {
+	if k.signer == nil {
+		return fmt.Errorf("signer account missing the signer")
+	}
+	return nil
+}
+
+func (k *signerKey) PrivateKey() (*crypto.PrivateKey, error) {
+	return nil, fmt.Errorf("the private key is not available for accounts that use a signer")
+}
+
+// a flow sdk signer that signs with a Signer
+type contextSigner struct {
+	ctx    context.Context
+	signer Signer
+}
+
+func (s *contextSigner) Sign(message []byte) ([]byte, error) {
+	return s.signer.Sign(s.ctx, message)
+}
+
+// the public key is not known to signers and is not needed when signing transactions
+func (s *contextSigner) PublicKey() crypto.PublicKey {
+	return nil
+}
+
+// AddSignerAccount sign for the account with the given name using the signer, the account does not have to be in flow.json.
+// The algorithms must be those of the account key with the index. WithSigner, WithPayloadSigner and the other options that take account names will use it
+func (o *OverflowState) AddSignerAccount(name string, address string, keyIndex uint32, sigAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm, signer Signer) (*accounts.Account, error) {
+	flowAddress := flow.HexToAddress(address)
+	if flowAddress == flow.EmptyAddress {
+		return nil, fmt.Errorf("%s is not a valid address", address)
+	}
+	if sigAlgo == crypto.UnknownSignatureAlgorithm || hashAlgo == crypto.UnknownHashAlgorithm {
+		return nil, fmt.Errorf("signer account %s needs the signature and hash algorithm of its key", name)
+	}
+
+	account := &accounts.Account{
+		Name:    name,
+		Address: flowAddress,
+		Key: &signerKey{
+			signer:   signer,
+			index:    keyIndex,
+			sigAlgo:  sigAlgo,
+			hashAlgo: hashAlgo,
+		},
+	}
+	o.SignerAccounts[name] = account
+	return account, nil
+}
+
+// the signer for the account, accounts from flow.json sign with their key
+func (o *OverflowState) signerFor(ctx context.Context, account *accounts.Account) (Signer, error) {
+	if key, ok := account.Key.(*signerKey); ok {
+		return key.signer, nil
+	}
+
+	signer, err := account.Key.Signer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return CallbackSigner(func(_ context.Context, message []byte) ([]byte, error) {
+		return signer.Sign(message)
+	}), nil
+}
+
+// sign for the account with the given name using the signer instead of a key in flow.json, the algorithms are those of the account key with the index, IE
+//
+//	o := Overflow(WithSignerAccount("admin", "0xf8d6e0586b0a20c7", 0, crypto.ECDSA_P256, crypto.SHA3_256, NewHTTPSigner("https://signer.example.com/admin")))
+//	o.Tx("admin_transaction", WithSigner("admin"))
+func WithSignerAccount(name string, address string, keyIndex uint32, sigAlgo crypto.SignatureAlgorithm, hashAlgo crypto.HashAlgorithm, signer Signer) OverflowOption {
+	return func(o *OverflowBuilder) {
+		if o.SignerAccounts == nil {
+			o.SignerAccounts = map[string]OverflowSignerAccount{}
+		}
+		o.SignerAccounts[name] = OverflowSignerAccount{Address: address, KeyIndex: keyIndex, SigAlgo: sigAlgo, HashAlgo: hashAlgo, Signer: signer}
+	}
+}
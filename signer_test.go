@@ -0,0 +1,127 @@
+package overflow
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	first, err := o.AccountE("first")
+	require.NoError(t, err)
+	privateKey, err := first.Key.PrivateKey()
+	require.NoError(t, err)
+
+	localSigner, err := NewLocalSigner(*privateKey, first.Key.HashAlgo())
+	require.NoError(t, err)
+
+	// a stand in for a remote signing service that holds the key of first
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var request HTTPSignRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		message, err := hex.DecodeString(request.Message)
+		require.NoError(t, err)
+		signature, err := localSigner.Sign(r.Context(), message)
+		require.NoError(t, err)
+		require.NoError(t, json.NewEncoder(w).Encode(HTTPSignResponse{Signature: hex.EncodeToString(signature)}))
+	}))
+	defer server.Close()
+
+	verify := func(t *testing.T, signature string, message string) {
+		bytes, err := hex.DecodeString(signature)
+		require.NoError(t, err)
+		hasher, err := crypto.NewHasher(first.Key.HashAlgo())
+		require.NoError(t, err)
+		valid, err := (*privateKey).PublicKey().Verify(bytes, append(flow.UserDomainTag[:], []byte(message)...), hasher)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	}
+
+	t.Run("Send transaction with http signer", func(t *testing.T) {
+		_, err := o.AddSignerAccount("remote", first.Address.HexWithPrefix(), 0, first.Key.SigAlgo(), first.Key.HashAlgo(), NewHTTPSigner(server.URL).WithHeader("Authorization", "Bearer secret"))
+		require.NoError(t, err)
+
+		before := requests.Load()
+		o.Tx("transaction { prepare(signer: &Account) { log(signer.address) } }", WithSigner("remote")).
+			AssertSuccess(t).
+			AssertEmulatorLog(t, first.Address.HexWithPrefix())
+		assert.Greater(t, requests.Load(), before)
+	})
+
+	t.Run("Sign user message with http signer", func(t *testing.T) {
+		signature, err := o.SignUserMessage("remote", "overflow")
+		require.NoError(t, err)
+		verify(t, signature, "overflow")
+	})
+
+	t.Run("Http signer error", func(t *testing.T) {
+		_, err := o.AddSignerAccount("unauthorized", first.Address.HexWithPrefix(), 0, first.Key.SigAlgo(), first.Key.HashAlgo(), NewHTTPSigner(server.URL))
+		require.NoError(t, err)
+
+		_, err = o.SignUserMessage("unauthorized", "overflow")
+		assert.ErrorContains(t, err, "status 401")
+	})
+
+	t.Run("Callback signer as payload signer", func(t *testing.T) {
+		var calls atomic.Int32
+		_, err := o.AddSignerAccount("callback", first.Address.HexWithPrefix(), 0, first.Key.SigAlgo(), first.Key.HashAlgo(), CallbackSigner(func(ctx context.Context, message []byte) ([]byte, error) {
+			calls.Add(1)
+			return localSigner.Sign(ctx, message)
+		}))
+		require.NoError(t, err)
+
+		o.Tx("transaction { prepare(signer: &Account) { } }",
+			WithPayloadSigner("callback"),
+			WithProposerServiceAccount(),
+		).AssertSuccess(t)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Signer accounts with builder option", func(t *testing.T) {
+		o2, err := OverflowTesting(WithSignerAccount("local", first.Address.HexWithPrefix(), 0, first.Key.SigAlgo(), first.Key.HashAlgo(), localSigner))
+		require.NoError(t, err)
+
+		signature, err := o2.SignUserMessage("local", "overflow")
+		require.NoError(t, err)
+		verify(t, signature, "overflow")
+		assert.Equal(t, first.Address.HexWithPrefix(), o2.Address("local"))
+	})
+
+	t.Run("Invalid address", func(t *testing.T) {
+		_, err := o.AddSignerAccount("invalid", "0x0", 0, first.Key.SigAlgo(), first.Key.HashAlgo(), localSigner)
+		assert.ErrorContains(t, err, "0x0 is not a valid address")
+	})
+
+	t.Run("Algorithms of the key", func(t *testing.T) {
+		account, err := o.AddSignerAccount("secp", first.Address.HexWithPrefix(), 0, crypto.ECDSA_secp256k1, crypto.SHA2_256, localSigner)
+		require.NoError(t, err)
+		assert.Equal(t, crypto.ECDSA_secp256k1, account.Key.SigAlgo())
+		assert.Equal(t, crypto.SHA2_256, account.Key.HashAlgo())
+
+		_, err = o.AddSignerAccount("unknown", first.Address.HexWithPrefix(), 0, crypto.UnknownSignatureAlgorithm, crypto.SHA2_256, localSigner)
+		assert.ErrorContains(t, err, "signer account unknown needs the signature and hash algorithm of its key")
+	})
+
+	t.Run("Http signer has a timeout", func(t *testing.T) {
+		assert.Equal(t, httpSignerTimeout, NewHTTPSigner(server.URL).Client.Timeout)
+	})
+}
@@ -0,0 +1,151 @@
+package overflow
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// the snapshots taken by name and the name of the emulator snapshot holding them
+type overflowSnapshots struct {
+	names map[string]string
+	count int
+	// the copy of a snapshot the emulator continues on after it was restored
+	current string
+}
+
+// the emulator continues on a snapshot it loads, so every snapshot and every restore gets a new emulator snapshot
+func (s *overflowSnapshots) next() string {
+	s.count++
+	return fmt.Sprintf("overflow%d", s.count)
+}
+
+// Snapshot save the current state of the in memory emulator under the given name, a snapshot with the same name is replaced.
+// A test suite can then branch from several known states without sending the transactions that lead up to them again, IE
+//
+//	o.Snapshot("listed")
+//	o.Tx("buy", ...)
+//	o.RestoreSnapshot("listed")
+func (o *OverflowState) Snapshot(name string) error {
+	if o.Emulator == nil {
+		return fmt.Errorf("snapshots are only supported on the in memory emulator")
+	}
+
+	unlock := o.lockEmulatorLog()
+	defer unlock()
+
+	snapshot := o.snapshots.next()
+	err := o.Emulator.CreateSnapshot(snapshot)
+	if err != nil {
+		return errors.Wrapf(err, "could not create snapshot %s", name)
+	}
+	replaced := o.snapshots.names[name]
+	o.snapshots.names[name] = snapshot
+	err = o.savePersistentState()
+	if err != nil {
+		return err
+	}
+	return o.removeSnapshotFile(replaced)
+}
+
+// RestoreSnapshot go back to the state of the emulator when the snapshot with the given name was taken, it can be restored as many times as needed
+func (o *OverflowState) RestoreSnapshot(name string) error {
+	if o.Emulator == nil {
+		return fmt.Errorf("snapshots are only supported on the in memory emulator")
+	}
+
+	unlock := o.lockEmulatorLog()
+	defer unlock()
+
+	snapshot, ok := o.snapshots.names[name]
+	if !ok {
+		return fmt.Errorf("snapshot %s does not exist", name)
+	}
+	if o.pendingBlock != nil && len(o.pendingBlock.transactions) > 0 {
+		return fmt.Errorf("there are %d transactions in the pending block, commit it before restoring snapshot %s", len(o.pendingBlock.transactions), name)
+	}
+
+	// the emulator keeps an in memory copy open after it loads another snapshot, so we hold on to the copy we leave and empty it afterwards
+	var previous *sql.Conn
+	if o.PersistentState == "" && o.snapshots.current != "" {
+		var err error
+		previous, err = o.store.DB().Conn(context.Background())
+		if err != nil {
+			return errors.Wrapf(err, "could not restore snapshot %s", name)
+		}
+		defer previous.Close()
+	}
+
+	err := o.Emulator.LoadSnapshot(snapshot)
+	if err != nil {
+		return errors.Wrapf(err, "could not restore snapshot %s", name)
+	}
+
+	// continue on a copy so that the snapshot stays as it was
+	working := o.snapshots.next()
+	err = o.Emulator.CreateSnapshot(working)
+	if err == nil {
+		err = o.Emulator.LoadSnapshot(working)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not restore snapshot %s", name)
+	}
+	replaced := o.snapshots.current
+	o.snapshots.current = working
+
+	// the sequence numbers we have seen may be ahead of the restored chain
+	o.forgetAllSequenceNumbers()
+	err = o.savePersistentState()
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		return emptyDatabase(previous)
+	}
+	return o.removeSnapshotFile(replaced)
+}
+
+// drop the tables of the database on the connection so the memory it holds is given back
+func emptyDatabase(conn *sql.Conn) error {
+	ctx := context.Background()
+	rows, err := conn.QueryContext(ctx, "SELECT name FROM sqlite_schema WHERE type = 'table' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return errors.Wrap(err, "could not list tables of the previous snapshot copy")
+	}
+	tables := []string{}
+	for rows.Next() {
+		var table string
+		err = rows.Scan(&table)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	for _, table := range tables {
+		_, err = conn.ExecContext(ctx, fmt.Sprintf("DROP TABLE %q", table))
+		if err != nil {
+			return errors.Wrap(err, "could not empty the previous snapshot copy")
+		}
+	}
+	_, err = conn.ExecContext(ctx, "VACUUM")
+	return errors.Wrap(err, "could not empty the previous snapshot copy")
+}
+
+// ListSnapshots the names of the snapshots that have been taken
+func (o *OverflowState) ListSnapshots() []string {
+	names := []string{}
+	for name := range o.snapshots.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
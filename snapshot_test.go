@@ -0,0 +1,89 @@
+package overflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshots(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	balance := func(t *testing.T) float64 {
+		value, err := o.Script(`access(all) fun main(address: Address): UFix64 { return getAccount(address).balance }`, WithArg("address", "first")).GetAsInterface()
+		require.NoError(t, err)
+		return value.(float64)
+	}
+
+	height := func(t *testing.T) uint64 {
+		block, err := o.GetLatestBlock(context.Background())
+		require.NoError(t, err)
+		return block.Height
+	}
+
+	mint := func(t *testing.T, amount float64) {
+		o.Tx("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", amount),
+		).AssertSuccess(t)
+	}
+
+	start := balance(t)
+	require.NoError(t, o.Snapshot("setup"))
+	mint(t, 10.0)
+	require.NoError(t, o.Snapshot("minted"))
+	mintedHeight := height(t)
+	assert.Equal(t, []string{"minted", "setup"}, o.ListSnapshots())
+
+	t.Run("Restore snapshot", func(t *testing.T) {
+		mint(t, 5.0)
+		require.NoError(t, o.RestoreSnapshot("minted"))
+		assert.InDelta(t, start+10.0, balance(t), 0.0001)
+		assert.Equal(t, mintedHeight, height(t))
+	})
+
+	t.Run("Restore the same snapshot again", func(t *testing.T) {
+		mint(t, 1.0)
+		assert.InDelta(t, start+11.0, balance(t), 0.0001)
+		require.NoError(t, o.RestoreSnapshot("minted"))
+		assert.InDelta(t, start+10.0, balance(t), 0.0001)
+	})
+
+	t.Run("Branch from earlier snapshot", func(t *testing.T) {
+		require.NoError(t, o.RestoreSnapshot("setup"))
+		assert.InDelta(t, start, balance(t), 0.0001)
+		mint(t, 2.0)
+		assert.InDelta(t, start+2.0, balance(t), 0.0001)
+
+		require.NoError(t, o.RestoreSnapshot("minted"))
+		assert.InDelta(t, start+10.0, balance(t), 0.0001)
+	})
+
+	t.Run("Copy we leave is emptied", func(t *testing.T) {
+		ctx := context.Background()
+		left, err := o.store.DB().Conn(ctx)
+		require.NoError(t, err)
+		defer left.Close()
+
+		require.NoError(t, o.RestoreSnapshot("setup"))
+		var tables int
+		require.NoError(t, left.QueryRowContext(ctx, "SELECT count(name) FROM sqlite_schema WHERE type = 'table'").Scan(&tables))
+		assert.Equal(t, 0, tables)
+		assert.InDelta(t, start, balance(t), 0.0001)
+	})
+
+	t.Run("Unknown snapshot", func(t *testing.T) {
+		assert.ErrorContains(t, o.RestoreSnapshot("foo"), "snapshot foo does not exist")
+	})
+
+	t.Run("Only in memory", func(t *testing.T) {
+		network := *o
+		network.Emulator = nil
+		assert.ErrorContains(t, network.Snapshot("foo"), "snapshots are only supported on the in memory emulator")
+	})
+}
@@ -0,0 +1,300 @@
+package overflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go/fvm/systemcontracts"
+	flowgo "github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flowkit/v2/accounts"
+	"github.com/pkg/errors"
+)
+
+// OverflowSponsor an account that pays the fees of transactions it is not otherwise part of and the fees it has paid.
+// It is added as payer and signs the envelope last and can be given a limit of how much it pays in total, IE
+//
+//	o, _ := OverflowTesting(WithSponsor("payer"), WithSponsorLimit("payer", 0.1))
+//	o.Tx("mint_tokens", WithSigner("first"), ...)
+//	o.GetSponsor("payer").Spent()
+type OverflowSponsor struct {
+	Name    string
+	Account *accounts.Account
+	// the most this sponsor pays in fees in total, zero means there is no limit
+	SpendingLimit float64
+
+	mutex sync.Mutex
+	fees  []OverflowSponsorFee
+	spent float64
+	// the most the transactions that are sent but not settled yet can cost
+	reserved      float64
+	feeParameters *overflowFeeParameters
+}
+
+// the most a sponsored transaction can cost, it is held against the spending limit until the fee the transaction paid is known
+type sponsorReservation struct {
+	sponsor *OverflowSponsor
+	fee     float64
+	settled bool
+}
+
+// the fee parameters of the network, see FlowFees.getFeeParameters
+type overflowFeeParameters struct {
+	SurgeFactor         float64 `json:"surgeFactor"`
+	InclusionEffortCost float64 `json:"inclusionEffortCost"`
+	ExecutionEffortCost float64 `json:"executionEffortCost"`
+}
+
+// the most a transaction with the given gas limit pays, the inclusion effort is 1.0 and the execution effort is the gas in UFix64
+func (p overflowFeeParameters) maxFee(gasLimit uint64) float64 {
+	return p.SurgeFactor * (p.InclusionEffortCost + p.ExecutionEffortCost*float64(gasLimit)/100000000)
+}
+
+// OverflowSponsorFee a fee paid by a sponsor
+type OverflowSponsorFee struct {
+	TransactionID   flow.Identifier
+	TransactionName string
+	Fee             float64
+	// the balance of the sponsor and the fee vault after the fee was paid
+	Balance FeeBalance
+}
+
+// Spent the total fees paid by the sponsor
+func (s *OverflowSponsor) Spent() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.spent
+}
+
+// Remaining how much the sponsor pays before it reaches its spending limit, zero if there is no limit
+func (s *OverflowSponsor) Remaining() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.SpendingLimit == 0 || s.spent+s.reserved > s.SpendingLimit {
+		return 0
+	}
+	return s.SpendingLimit - s.spent - s.reserved
+}
+
+// Fees the ledger of fees paid by the sponsor in the order they were paid
+func (s *OverflowSponsor) Fees() []OverflowSponsorFee {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	fees := make([]OverflowSponsorFee, len(s.fees))
+	copy(fees, s.fees)
+	return fees
+}
+
+// a sponsor that has reached its limit does not pay for more transactions
+func (s *OverflowSponsor) checkLimit() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.SpendingLimit != 0 && s.spent >= s.SpendingLimit {
+		return fmt.Errorf("sponsor %s has spent %v and reached its spending limit of %v", s.Name, s.spent, s.SpendingLimit)
+	}
+	return nil
+}
+
+// hold the most the transaction can cost against the spending limit, so transactions sent at the same time cannot go over it together
+func (s *OverflowSponsor) reserve(maxFee float64) (*sponsorReservation, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.SpendingLimit != 0 && s.spent+s.reserved+maxFee > s.SpendingLimit {
+		return nil, fmt.Errorf("sponsor %s has spent %v and reserved %v, paying up to %v more would go over its spending limit of %v", s.Name, s.spent, s.reserved, maxFee, s.SpendingLimit)
+	}
+	s.reserved += maxFee
+	return &sponsorReservation{sponsor: s, fee: maxFee}, nil
+}
+
+func (s *OverflowSponsor) record(fee OverflowSponsorFee) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.fees = append(s.fees, fee)
+	s.spent += fee.Fee
+}
+
+// give back what was reserved for the transaction and record the fee it paid if it is known, settling more than once is a no-op for the reservation
+func (r *sponsorReservation) settle(fee *OverflowSponsorFee) {
+	if r == nil {
+		return
+	}
+	s := r.sponsor
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !r.settled {
+		s.reserved -= r.fee
+		r.settled = true
+	}
+	if fee != nil {
+		s.fees = append(s.fees, *fee)
+		s.spent += fee.Fee
+	}
+}
+
+// AddSponsor let the account pay for transactions sent with WithInteractionSponsor, a spending limit of zero means there is no limit
+func (o *OverflowState) AddSponsor(name string, spendingLimit float64) (*OverflowSponsor, error) {
+	account, err := o.accountByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	sponsor := &OverflowSponsor{
+		Name:          name,
+		Account:       account,
+		SpendingLimit: spendingLimit,
+		fees:          []OverflowSponsorFee{},
+	}
+	o.Sponsors[account.Address.HexWithPrefix()] = sponsor
+	return sponsor, nil
+}
+
+// GetSponsor the sponsor with the given name, nil if the account is not a sponsor
+func (o *OverflowState) GetSponsor(name string) *OverflowSponsor {
+	account, err := o.accountByName(name)
+	if err != nil {
+		return nil
+	}
+	return o.Sponsors[account.Address.HexWithPrefix()]
+}
+
+// the sponsor that pays for the interaction if any
+func (oib OverflowInteractionBuilder) sponsor() (*OverflowSponsor, error) {
+	if oib.Sponsor == "" {
+		return nil, nil
+	}
+	sponsor := oib.Overflow.GetSponsor(oib.Sponsor)
+	if sponsor == nil {
+		return nil, fmt.Errorf("%s is not a sponsor, add it with WithSponsor or AddSponsor", oib.Sponsor)
+	}
+	return sponsor, sponsor.checkLimit()
+}
+
+// the most the interaction can cost the sponsor, it is only needed if the sponsor has a spending limit
+func (oib OverflowInteractionBuilder) sponsorMaxFee(sponsor *OverflowSponsor) (float64, error) {
+	if sponsor == nil || sponsor.SpendingLimit == 0 || oib.DryRun {
+		return 0, nil
+	}
+
+	sponsor.mutex.Lock()
+	parameters := sponsor.feeParameters
+	sponsor.mutex.Unlock()
+	if parameters == nil {
+		var err error
+		parameters, err = oib.Overflow.getFeeParameters(oib.Ctx)
+		if err != nil {
+			return 0, errors.Wrapf(err, "could not get the fee parameters to reserve fees for sponsor %s", sponsor.Name)
+		}
+		sponsor.mutex.Lock()
+		sponsor.feeParameters = parameters
+		sponsor.mutex.Unlock()
+	}
+	return parameters.maxFee(oib.GasLimit), nil
+}
+
+// read the fee parameters from the FlowFees contract of the chain we are on
+func (o *OverflowState) getFeeParameters(ctx context.Context) (*overflowFeeParameters, error) {
+	var chainID flowgo.ChainID
+	if o.Emulator != nil {
+		chainID = o.Emulator.GetChain().ChainID()
+	} else if o.AccessClient != nil {
+		parameters, err := o.AccessClient.GetNetworkParameters(ctx)
+		if err != nil {
+			return nil, err
+		}
+		chainID = flowgo.ChainID(parameters.ChainID)
+	} else {
+		return nil, fmt.Errorf("not connected to a network")
+	}
+
+	fees := systemcontracts.SystemContractsForChain(chainID).FlowFees
+	code := fmt.Sprintf(`import FlowFees from 0x%s
+
+access(all) fun main(): FlowFees.FeeParameters {
+	return FlowFees.getFeeParameters()
+}`, fees.Address.Hex())
+
+	parameters := &overflowFeeParameters{}
+	err := o.Script(code).MarshalAs(parameters)
+	if err != nil {
+		return nil, err
+	}
+	return parameters, nil
+}
+
+// a transaction built elsewhere is sponsored if its payer is a sponsor that is not otherwise part of it
+func (o *OverflowState) isSponsored(tx *flow.Transaction) bool {
+	if _, ok := o.Sponsors[tx.Payer.HexWithPrefix()]; !ok {
+		return false
+	}
+	if tx.ProposalKey.Address == tx.Payer {
+		return false
+	}
+	for _, authorizer := range tx.Authorizers {
+		if authorizer == tx.Payer {
+			return false
+		}
+	}
+	return true
+}
+
+// add the fee of the transaction to the ledger of the sponsor that paid it and give back what was reserved for it
+func (o *OverflowState) recordSponsoredFee(result *OverflowResult) {
+	fee, _ := result.Fee["amount"].(float64)
+	if result.DryRun || !result.sponsored || fee == 0 {
+		result.sponsorReservation.settle(nil)
+		return
+	}
+	paid := OverflowSponsorFee{
+		TransactionID:   result.Id,
+		TransactionName: result.Name,
+		Fee:             fee,
+		Balance:         result.Balance,
+	}
+	if result.sponsorReservation != nil {
+		result.sponsorReservation.settle(&paid)
+		return
+	}
+	sponsor, ok := o.Sponsors[result.Transaction.Payer.HexWithPrefix()]
+	if !ok {
+		return
+	}
+	sponsor.record(paid)
+}
+
+// let the account pay the fees for all transactions, the signers of a transaction still authorize it
+func WithSponsor(name string) OverflowOption {
+	return func(o *OverflowBuilder) {
+		o.Sponsor = name
+		if o.SponsorLimits == nil {
+			o.SponsorLimits = map[string]float64{}
+		}
+		if _, ok := o.SponsorLimits[name]; !ok {
+			o.SponsorLimits[name] = 0
+		}
+	}
+}
+
+// add the account as a sponsor that stops paying for transactions when it has paid the given amount in fees
+func WithSponsorLimit(name string, spendingLimit float64) OverflowOption {
+	return func(o *OverflowBuilder) {
+		if o.SponsorLimits == nil {
+			o.SponsorLimits = map[string]float64{}
+		}
+		o.SponsorLimits[name] = spendingLimit
+	}
+}
+
+// let the given sponsor pay the fees of this transaction
+func WithInteractionSponsor(name string) OverflowInteractionOption {
+	return func(oib *OverflowInteractionBuilder) {
+		oib.Sponsor = name
+	}
+}
+
+// do not let the sponsor pay for this transaction
+func WithoutSponsor() OverflowInteractionOption {
+	return func(oib *OverflowInteractionBuilder) {
+		oib.Sponsor = ""
+	}
+}
@@ -0,0 +1,113 @@
+package overflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSponsor(t *testing.T) {
+	o, err := OverflowTesting(WithSponsor("second"), WithSponsorLimit("second", 0.00002))
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	sponsor := o.GetSponsor("second")
+	require.NotNil(t, sponsor)
+	assert.Nil(t, o.GetSponsor("first"))
+
+	code := `transaction { prepare(signer: &Account) { log(signer.address) } }`
+
+	t.Run("Sponsor pays for transaction", func(t *testing.T) {
+		result := o.Tx(code, WithSigner("first"))
+		result.AssertSuccess(t)
+
+		assert.Equal(t, o.Address("second"), result.Transaction.Payer.HexWithPrefix())
+		assert.Equal(t, o.Address("first"), result.Transaction.ProposalKey.Address.HexWithPrefix())
+		require.Len(t, result.Transaction.Authorizers, 1)
+		assert.Equal(t, o.Address("first"), result.Transaction.Authorizers[0].HexWithPrefix())
+
+		fees := sponsor.Fees()
+		require.Len(t, fees, 1)
+		assert.Equal(t, result.Id, fees[0].TransactionID)
+		assert.Equal(t, result.Fee["amount"], fees[0].Fee)
+		assert.Equal(t, result.Balance, fees[0].Balance)
+		assert.Equal(t, fees[0].Fee, sponsor.Spent())
+	})
+
+	t.Run("Transaction without sponsor", func(t *testing.T) {
+		result := o.Tx(code, WithSigner("first"), WithoutSponsor())
+		result.AssertSuccess(t)
+		assert.Equal(t, o.Address("first"), result.Transaction.Payer.HexWithPrefix())
+		assert.Len(t, sponsor.Fees(), 1)
+	})
+
+	t.Run("Sponsor pays for its own transaction", func(t *testing.T) {
+		result := o.Tx(code, WithSigner("second"))
+		result.AssertSuccess(t)
+		assert.Equal(t, o.Address("second"), result.Transaction.Payer.HexWithPrefix())
+		assert.Len(t, sponsor.Fees(), 1)
+	})
+
+	t.Run("Sponsor pays for offline transaction", func(t *testing.T) {
+		tx, err := o.BuildOfflineTx(code, WithSigner("first"))
+		require.NoError(t, err)
+		require.NoError(t, o.SignOfflineTransaction(tx, "first"))
+		require.NoError(t, o.SignOfflineTransaction(tx, "second"))
+
+		result := o.SendOfflineTransaction(tx)
+		result.AssertSuccess(t)
+		fees := sponsor.Fees()
+		require.Len(t, fees, 2)
+		assert.Equal(t, result.Id, fees[1].TransactionID)
+	})
+
+	t.Run("Unknown sponsor", func(t *testing.T) {
+		result := o.Tx(code, WithSigner("first"), WithInteractionSponsor("first"))
+		assert.ErrorContains(t, result.Err, "first is not a sponsor")
+	})
+
+	t.Run("Spending limit", func(t *testing.T) {
+		for sponsor.Remaining() > 0 {
+			o.Tx(code, WithSigner("first")).AssertSuccess(t)
+		}
+		result := o.Tx(code, WithSigner("first"))
+		assert.ErrorContains(t, result.Err, "sponsor second has spent")
+		assert.ErrorContains(t, result.Err, "reached its spending limit of 2e-05")
+	})
+}
+
+func TestSponsorReservesFees(t *testing.T) {
+	o, err := OverflowTesting(WithSponsorLimit("second", 0.00001), WithManualBlockCommit())
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	sponsor := o.GetSponsor("second")
+	require.NotNil(t, sponsor)
+
+	code := `transaction { prepare(signer: &Account) { log(signer.address) } }`
+
+	t.Run("Pending transactions cannot go over the limit together", func(t *testing.T) {
+		first := o.Tx(code, WithSigner("first"), WithInteractionSponsor("second"))
+		require.NoError(t, first.Err)
+		assert.True(t, first.Pending)
+		assert.Equal(t, 0.0, sponsor.Remaining())
+
+		second := o.Tx(code, WithSigner("first"), WithInteractionSponsor("second"))
+		assert.ErrorContains(t, second.Err, "would go over its spending limit of 1e-05")
+
+		results, err := o.CommitBlock()
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		results[0].AssertSuccess(t)
+		assert.Equal(t, 0.00001, sponsor.Spent())
+		assert.Len(t, sponsor.Fees(), 1)
+	})
+
+	t.Run("Reservation is given back when the transaction is not sent", func(t *testing.T) {
+		sponsor.SpendingLimit = 0.00002
+		_, err := o.BuildOfflineTx(code, WithSigner("first"), WithInteractionSponsor("second"))
+		require.NoError(t, err)
+		assert.InDelta(t, 0.00001, sponsor.Remaining(), 1e-12)
+	})
+}
@@ -11,6 +11,8 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bjartek/underflow"
 	"github.com/enescakir/emoji"
@@ -23,11 +25,13 @@ import (
 	"github.com/onflow/cadence/runtime"
 	"github.com/onflow/cadence/sema"
 	"github.com/onflow/flixkit-go/v2/flixkit"
+	"github.com/onflow/flow-emulator/emulator"
+	"github.com/onflow/flow-emulator/storage/sqlite"
 	"github.com/onflow/flow-go-sdk"
+	grpcAccess "github.com/onflow/flow-go-sdk/access/grpc"
 	"github.com/onflow/flowkit/v2"
 	"github.com/onflow/flowkit/v2/accounts"
 	"github.com/onflow/flowkit/v2/config"
-	"github.com/onflow/flowkit/v2/gateway"
 	"github.com/onflow/flowkit/v2/output"
 	"github.com/onflow/flowkit/v2/project"
 	"github.com/pkg/errors"
@@ -109,21 +113,67 @@ type OverflowState struct {
 	// the services from flowkit to performed operations on
 	Flowkit *flowkit.Flowkit
 
-	EmulatorGatway *gateway.EmulatorGateway
+	// the gateway to the in memory emulator, only set when running in memory.
+	// It used to be the *gateway.EmulatorGateway of flowkit, overflow now creates the emulator itself since flowkit does not give access to it
+	EmulatorGatway *OverflowEmulatorGateway
+	// the emulator behind EmulatorGatway, only set when running in memory
+	Emulator *emulator.Blockchain
 
+	// if set queries for historical data that the access node does not have are retried against this archive node, see WithArchiveNode
 	ArchiveFlowkit *flowkit.Flowkit
 
+	// a client directly to the access node, only set when not running in memory. Used for queries flowkit does not support like finalized blocks
+	AccessClient *grpcAccess.Client
+
+	// the status a block must have to be the latest block and how many blocks to hold back when fetching events or crawling, see WithBlockStatus
+	BlockStatus       OverflowBlockStatus
+	ConfirmationDepth uint64
+
 	// Configured variables that are taken from the builder since we need them in the execution of overflow later on
 	Network                      config.Network
 	PrependNetworkToAccountNames bool
 	ServiceAccountSuffix         string
 	Gas                          int
+	RetryMax                     int
+	RetryBackoff                 time.Duration
+	// the name of the sponsor that pays the fees of all transactions, see WithSponsor
+	Sponsor string
 
 	// flowkit, emulator and emulator debug log uses three different logging technologies so we have them all stored here
 	// this flowkit Logger can go away when we can remove deprecations!
 	Logger   output.Logger
 	Log      *bytes.Buffer
 	LogLevel int
+	// transactions sent from several goroutines take turns writing to and reading the emulator log
+	logMutex *sync.Mutex
+
+	// key pools used as proposal keys for the account with the given address, see AddProposerKeyPool
+	ProposerKeyPools map[string]*OverflowProposerKeyPool
+
+	// accounts that pay the fees of transactions by their address, see AddSponsor
+	Sponsors map[string]*OverflowSponsor
+
+	// transactions added to the pending block of the emulator when blocks are committed manually, see SetManualBlockCommit
+	pendingBlock *overflowPendingBlock
+
+	// named snapshots of the emulator, see Snapshot
+	snapshots *overflowSnapshots
+
+	// the clock of the in memory emulator that sets the time of blocks, see AdvanceTime
+	clock *overflowClock
+
+	// the directory the state of the in memory emulator is stored in and if it was reopened with state from before, see WithPersistentState
+	PersistentState string
+	Reopened        bool
+
+	// the store of the in memory emulator when it is not the default, see WithPersistentState
+	store *sqlite.Store
+
+	// the network state the in memory emulator was started with, see WithForkedState
+	Fork *OverflowNetworkExport
+
+	// accounts that are signed for by a Signer by their logical name, see AddSignerAccount
+	SignerAccounts map[string]*accounts.Account
 
 	// If there was an error starting overflow it is stored here
 	Error error
@@ -324,6 +374,10 @@ func (o *OverflowState) AccountPublicKey(name string) (string, error) {
 // AccountE fetch an account from State
 // Note that if `PrependNetworkToAccountNames` is specified it is prefixed with the network so that you can use the same logical name across networks
 func (o *OverflowState) AccountE(key string) (*accounts.Account, error) {
+	if account, ok := o.SignerAccounts[key]; ok {
+		return account, nil
+	}
+
 	if o.PrependNetworkToAccountNames {
 		key = fmt.Sprintf("%s-%s", o.Network.Name, key)
 	}
@@ -524,8 +578,12 @@ func (o *OverflowState) GetAccount(ctx context.Context, key string) (*flow.Accou
 	return o.Flowkit.GetAccount(ctx, rawAddress)
 }
 
+// read the log of the in memory emulator, other networks have no log and many transactions can be sent to them at once so the buffer is not touched
 func (o OverflowState) readLog() ([]OverflowEmulatorLogMessage, error) {
 	var logMessage []OverflowEmulatorLogMessage
+	if o.EmulatorGatway == nil {
+		return logMessage, nil
+	}
 	dec := json.NewDecoder(o.Log)
 	for {
 		var msg map[string]interface{}
@@ -557,6 +615,16 @@ func (o OverflowState) readLog() ([]OverflowEmulatorLogMessage, error) {
 	return logMessage, nil
 }
 
+// reset the emulator log and lock it when running in memory, the returned function unlocks it again
+func (o *OverflowState) lockEmulatorLog() func() {
+	if o.EmulatorGatway == nil {
+		return func() {}
+	}
+	o.logMutex.Lock()
+	o.Log.Reset()
+	return o.logMutex.Unlock
+}
+
 // If you store this in a struct and add arguments to it it will not reset between calls
 func (o *OverflowState) TxFN(outerOpts ...OverflowInteractionOption) OverflowTransactionFunction {
 	return func(filename string, opts ...OverflowInteractionOption) *OverflowResult {
@@ -582,7 +650,15 @@ func (o *OverflowState) Tx(filename string, opts ...OverflowInteractionOption) *
 }
 
 func (o *OverflowState) sendTx(ftb *OverflowInteractionBuilder) *OverflowResult {
-	result := ftb.Send()
+	return o.finishTx(ftb, ftb.Send())
+}
+
+// print the result and run the assertions configured on the builder
+func (o *OverflowState) finishTx(ftb *OverflowInteractionBuilder, result *OverflowResult) *OverflowResult {
+	// this is done when the block is committed
+	if result.Pending {
+		return result
+	}
 
 	if ftb.PrintOptions != nil && !ftb.NoLog {
 		po := *ftb.PrintOptions
@@ -614,6 +690,14 @@ func (o *OverflowState) sendTx(ftb *OverflowInteractionBuilder) *OverflowResult
 		result.AssertSuccess(ot.T)
 
 		for _, ea := range ot.Events {
+			if len(ea.Conditions) > 0 {
+				if ea.Require {
+					result.RequireEventWhere(ot.T, ea.Suffix, ea.Conditions...)
+				} else {
+					result.AssertEventWhere(ot.T, ea.Suffix, ea.Conditions...)
+				}
+				continue
+			}
 			if ea.Require {
 				result.RequireEvent(ot.T, ea.Suffix, ea.Fields)
 			} else {
@@ -627,18 +711,16 @@ func (o *OverflowState) sendTx(ftb *OverflowInteractionBuilder) *OverflowResult
 
 // get the latest block
 func (o *OverflowState) GetLatestBlock(ctx context.Context) (*flow.Block, error) {
-	bc, err := flowkit.NewBlockQuery("latest")
-	if err != nil {
-		return nil, err
-	}
-	return o.Flowkit.GetBlock(ctx, bc)
+	return o.GetLatestBlockWithStatus(ctx, o.BlockStatus)
 }
 
 // get block at a given height
 func (o *OverflowState) GetBlockAtHeight(ctx context.Context, height uint64) (*flow.Block, error) {
 	ctx = logging.InjectLogField(ctx, "block_height", height)
-	bc := flowkit.BlockQuery{Height: height}
-	return o.Flowkit.GetBlock(ctx, bc)
+	// ask the gateway directly, flowkit turns the error into text and the archive fallback needs the status code
+	return withArchiveFallback(o, func(fk *flowkit.Flowkit) (*flow.Block, error) {
+		return fk.Gateway().GetBlockByHeight(ctx, height)
+	})
 }
 
 // blockId should be a hexadecimal string
@@ -655,25 +737,7 @@ func (o *OverflowState) BuildInteraction(filename string, interactionType string
 	if interactionType == "script" {
 		path = o.ScriptBasePath
 	}
-	ftb := &OverflowInteractionBuilder{
-		Ctx:            context.Background(),
-		Overflow:       o,
-		Payer:          nil,
-		Arguments:      []cadence.Value{},
-		PayloadSigners: []*accounts.Account{},
-		GasLimit:       uint64(o.Gas),
-		BasePath:       path,
-		NamedArgs:      map[string]interface{}{},
-		NoLog:          false,
-		PrintOptions:   o.PrintOptions,
-		ScriptQuery:    nil,
-		Testing:        OverflowTestingAsssertions{},
-	}
-
-	for _, opt := range opts {
-		opt(ftb)
-	}
-
+	ftb := o.newInteractionBuilder(path, opts)
 	if ftb.Error != nil {
 		return ftb
 	}
@@ -722,6 +786,33 @@ func (o *OverflowState) BuildInteraction(filename string, interactionType string
 	return ftb
 }
 
+// create an interaction builder with the default values and apply the options to it
+func (o *OverflowState) newInteractionBuilder(path string, opts []OverflowInteractionOption) *OverflowInteractionBuilder {
+	ftb := &OverflowInteractionBuilder{
+		Ctx:                context.Background(),
+		Overflow:           o,
+		Payer:              nil,
+		Arguments:          []cadence.Value{},
+		PayloadSigners:     []*accounts.Account{},
+		GasLimit:           uint64(o.Gas),
+		BasePath:           path,
+		NamedArgs:          map[string]interface{}{},
+		NoLog:              false,
+		PrintOptions:       o.PrintOptions,
+		ScriptQuery:        nil,
+		Testing:            OverflowTestingAsssertions{},
+		StatusPollInterval: time.Second,
+		RetryMax:           o.RetryMax,
+		RetryBackoff:       o.RetryBackoff,
+		Sponsor:            o.Sponsor,
+	}
+
+	for _, opt := range opts {
+		opt(ftb)
+	}
+	return ftb
+}
+
 // Parse the given overflow state into a solution/npm-module
 func (o *OverflowState) ParseAll() (*OverflowSolution, error) {
 	return o.ParseAllWithConfig(false, []string{}, []string{})
@@ -913,7 +1004,31 @@ func (o *OverflowState) GetCoverageReport() *runtime.CoverageReport {
 }
 
 func (o *OverflowState) RollbackToBlockHeight(height uint64) error {
-	return o.EmulatorGatway.RollbackToBlockHeight(height)
+	unlock := o.lockEmulatorLog()
+	defer unlock()
+	return o.rollbackToBlockHeight(height)
+}
+
+// roll back while the emulator is already held
+func (o *OverflowState) rollbackToBlockHeight(height uint64) error {
+	err := o.EmulatorGatway.RollbackToBlockHeight(height)
+	if err != nil {
+		return err
+	}
+
+	// the transactions waiting in the pending block are thrown away with the blocks after the height
+	if o.pendingBlock != nil {
+		for _, tx := range o.pendingBlock.transactions {
+			tx.result.Pending = false
+			tx.result.Err = fmt.Errorf("the emulator was rolled back to height %d before the block was committed", height)
+			tx.result.sponsorReservation.settle(nil)
+		}
+		o.pendingBlock = newPendingBlock()
+	}
+
+	// the sequence numbers we have seen may be ahead of the chain we rolled back to
+	o.forgetAllSequenceNumbers()
+	return nil
 }
 
 // this methods create test accounts that can be used in WithManualSigner, they are not in flow json
@@ -0,0 +1,105 @@
+package overflow
+
+import (
+	"context"
+	"time"
+)
+
+// SubscribeEvents polls for new blocks and sends the events in the given filter on the returned channel in the order they were emitted.
+// The keys of the filter are the event types to subscribe to and the values the fields to ignore, just like for FetchEvents.
+//
+// By default only events in blocks after the current latest block are sent, use WithFromHeight, WithLastBlocks or WithProgressFile to start earlier.
+// Events are never sent before their block has the status set with WithFetchBlockStatus and WithFetchConfirmationDepth blocks on top of it, these default to the settings on OverflowState.
+// The subscription stops when the context is done or fetching events fails, the error is then sent on the error channel and both channels are closed.
+func (o *OverflowState) SubscribeEvents(ctx context.Context, filter OverflowEventFilter, opts ...OverflowEventFetcherOption) (<-chan OverflowEvent, <-chan error) {
+	allOpts := []OverflowEventFetcherOption{WithEvents(filter), withOnlyNewBlocks()}
+	allOpts = append(allOpts, opts...)
+	allOpts = append(allOpts, WithFetchContext(ctx))
+	e := o.eventFetcher(allOpts)
+
+	events := make(chan OverflowEvent)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(events)
+
+		err := e.subscribe(func(event OverflowEvent) error {
+			select {
+			case events <- event:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && err != ctx.Err() {
+			errs <- err
+		}
+	}()
+	return events, errs
+}
+
+func (e *OverflowEventFetcherBuilder) subscribe(callback func(OverflowEvent) error) error {
+	if e.OnlyNewBlocks {
+		present := false
+		if e.ProgressFile != "" {
+			var err error
+			present, err = exists(e.ProgressFile)
+			if err != nil {
+				return err
+			}
+		}
+
+		// a progress file takes precedence, if not we start after the current block
+		if !present {
+			block, err := e.OverflowState.GetLatestBlockWithStatus(e.Ctx, e.BlockStatus)
+			if err != nil {
+				return err
+			}
+			e.FromIndex = int64(block.Height + 1)
+		}
+	}
+	e.EndAtCurrentHeight = true
+
+	for {
+		height, ok, err := e.OverflowState.latestConfirmedHeight(e.Ctx, e.BlockStatus, e.ConfirmationDepth)
+		if err != nil {
+			return err
+		}
+
+		if ok && (e.FromIndex < 0 || uint64(e.FromIndex) <= height) {
+			result := e.fetch()
+			if result.Error != nil {
+				return result.Error
+			}
+
+			for _, blockEvents := range result.Blocks {
+				for _, event := range blockEvents.EventList {
+					err := callback(event)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			// the progress only moves on once all events have been delivered, so they are sent again after a restart if delivering them failed
+			err = e.saveProgress(result)
+			if err != nil {
+				return err
+			}
+			e.FromIndex = int64(result.To + 1)
+		}
+
+		select {
+		case <-e.Ctx.Done():
+			return e.Ctx.Err()
+		case <-time.After(e.PollInterval):
+		}
+	}
+}
+
+func withOnlyNewBlocks() OverflowEventFetcherOption {
+	return func(e *OverflowEventFetcherBuilder) {
+		e.OnlyNewBlocks = true
+	}
+}
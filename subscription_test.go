@@ -0,0 +1,106 @@
+package overflow
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeEvents(t *testing.T) {
+	mintedEvent := "A.0ae53cb6e3f42a79.FlowToken.TokensMinted"
+	depositedEvent := "A.0ae53cb6e3f42a79.FlowToken.TokensDeposited"
+
+	t.Run("Subscribe to new events", func(t *testing.T) {
+		o, err := OverflowTesting()
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		events, errs := o.SubscribeEvents(ctx, OverflowEventFilter{mintedEvent: {}, depositedEvent: {"amount"}}, WithPollInterval(10*time.Millisecond))
+
+		o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+
+		received := []OverflowEvent{}
+		for len(received) < 2 {
+			select {
+			case event := <-events:
+				received = append(received, event)
+			case err := <-errs:
+				require.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "timed out waiting for events")
+			}
+		}
+
+		assert.Equal(t, mintedEvent, received[0].Name)
+		assert.Equal(t, 1.0, received[0].Fields["amount"])
+		assert.Equal(t, depositedEvent, received[1].Name)
+		assert.NotContains(t, received[1].Fields, "amount")
+		assert.Equal(t, []string{"0x179b6b1cb6755e31"}, received[1].Addresses["to"])
+
+		cancel()
+		for range events {
+		}
+		assert.NoError(t, <-errs)
+	})
+
+	t.Run("Subscribe from height", func(t *testing.T) {
+		o, err := OverflowTesting()
+		require.NoError(t, err)
+		o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		events, errs := o.SubscribeEvents(ctx, OverflowEventFilter{mintedEvent: {}}, WithFromHeight(6))
+
+		select {
+		case event := <-events:
+			assert.Equal(t, 1.0, event.Fields["amount"])
+		case err := <-errs:
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("Resume after delivering events failed", func(t *testing.T) {
+		o, err := OverflowTesting()
+		require.NoError(t, err)
+		o.Tx("mint_tokens", WithSignerServiceAccount(), WithArg("recipient", "first"), WithArg("amount", 1.0)).AssertSuccess(t)
+		progressFile := filepath.Join(t.TempDir(), "progress")
+
+		e := o.eventFetcher([]OverflowEventFetcherOption{WithEvent(mintedEvent), WithFromHeight(6), WithProgressFile(progressFile)})
+		err = e.subscribe(func(event OverflowEvent) error {
+			return fmt.Errorf("consumer crashed")
+		})
+		assert.ErrorContains(t, err, "consumer crashed")
+
+		progress, err := os.ReadFile(progressFile)
+		require.NoError(t, err)
+		assert.Equal(t, "6", string(progress))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		events, errs := o.SubscribeEvents(ctx, OverflowEventFilter{mintedEvent: {}}, WithProgressFile(progressFile))
+		select {
+		case event := <-events:
+			assert.Equal(t, 1.0, event.Fields["amount"])
+		case err := <-errs:
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("Subscribe without events fails", func(t *testing.T) {
+		o, err := OverflowTesting()
+		require.NoError(t, err)
+
+		events, errs := o.SubscribeEvents(context.Background(), OverflowEventFilter{}, WithFromHeight(0))
+		assert.ErrorContains(t, <-errs, "specify at least one event to fetch")
+		_, ok := <-events
+		assert.False(t, ok)
+	})
+}
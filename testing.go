@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/onflow/cadence/runtime"
 	"github.com/onflow/flow-go/utils/io"
 	"github.com/stretchr/testify/require"
 )
@@ -35,13 +36,37 @@ func (ot *OverflowTest) Run(t *testing.T, name string, f func(t *testing.T)) {
 	require.NoError(t, err)
 }
 
+// RunFromSnapshot restore the named snapshot before running the test and go back to the state after setup when it is done
+func (ot *OverflowTest) RunFromSnapshot(t *testing.T, snapshot string, name string, f func(t *testing.T)) {
+	t.Helper()
+	err := ot.O.RestoreSnapshot(snapshot)
+	require.NoError(t, err)
+	t.Run(name, f)
+	err = ot.Reset()
+	require.NoError(t, err)
+}
+
+// RunWithClock run the test like Run and put the block time back to what it was before when it is done
+func (ot *OverflowTest) RunWithClock(t *testing.T, name string, f func(t *testing.T)) {
+	t.Helper()
+	clock := ot.O.clock.state()
+	ot.Run(t, name, f)
+	err := ot.O.changeClock(func(c *overflowClock) {
+		c.restore(clock)
+	})
+	require.NoError(t, err)
+}
+
 func (ot *OverflowTest) Teardown() {
 
 	report := ot.O.GetCoverageReport()
 	if report == nil {
 		return
 	}
+	writeCoverageReport(report)
+}
 
+func writeCoverageReport(report *runtime.CoverageReport) {
 	bytes, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
 		panic(err)
@@ -50,7 +75,6 @@ func (ot *OverflowTest) Teardown() {
 	if err != nil {
 		panic(err)
 	}
-
 }
 
 func SetupTest(opts []OverflowOption, setup func(o *OverflowState) error) (*OverflowTest, error) {
@@ -62,13 +86,16 @@ func SetupTest(opts []OverflowOption, setup func(o *OverflowState) error) (*Over
 		return nil, o.Error
 	}
 
-	err := setup(o)
-	if err != nil {
-		return nil, err
+	// the setup has already been run on persistent state that is reopened
+	if !o.Reopened {
+		err := setup(o)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if o.Error != nil {
-		return nil, err
+		return nil, o.Error
 	}
 
 	block, err := o.GetLatestBlock(context.Background())
@@ -11,6 +11,7 @@ import (
 	"github.com/onflow/cadence/common"
 	"github.com/onflow/cadence/parser"
 	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flowkit/v2"
 )
 
 type FilterFunction func(OverflowTransaction) bool
@@ -36,6 +37,7 @@ type OverflowTransaction struct {
 	ProposalKey        flow.ProposalKey
 	Fee                float64
 	TransactionIndex   int
+	BlockHeight        uint64
 	GasLimit           uint64
 	GasUsed            uint64
 	ExecutionEffort    float64
@@ -153,7 +155,12 @@ func (o *OverflowState) CreateOverflowTransaction(blockId string, transactionRes
 
 func (o *OverflowState) GetOverflowTransactionById(ctx context.Context, id flow.Identifier) (*OverflowTransaction, error) {
 	ctx = logging.InjectLogField(ctx, "transaction_id", id)
-	tx, txr, err := o.Flowkit.GetTransactionByID(ctx, id, false)
+	var txr *flow.TransactionResult
+	tx, err := withArchiveFallback(o, func(fk *flowkit.Flowkit) (*flow.Transaction, error) {
+		tx, result, err := fk.GetTransactionByID(ctx, id, false)
+		txr = result
+		return tx, err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +182,12 @@ func (o *OverflowState) GetTransactionById(ctx context.Context, id flow.Identifi
 
 func (o *OverflowState) GetTransactionsByBlockId(ctx context.Context, id flow.Identifier) ([]*flow.Transaction, []*flow.TransactionResult, error) {
 	ctx = logging.InjectLogField(ctx, "block_id", id)
-	tx, txr, err := o.Flowkit.GetTransactionsByBlockID(ctx, id)
+	var txr []*flow.TransactionResult
+	tx, err := withArchiveFallback(o, func(fk *flowkit.Flowkit) ([]*flow.Transaction, error) {
+		tx, results, err := fk.GetTransactionsByBlockID(ctx, id)
+		txr = results
+		return tx, err
+	})
 	if err != nil {
 		return nil, nil, err
 	}
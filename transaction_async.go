@@ -0,0 +1,314 @@
+package overflow
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/pkg/errors"
+)
+
+// OverflowAsyncResult a transaction that has been sent but might not be sealed yet
+type OverflowAsyncResult struct {
+	// the id of the transaction when it was first sent, the result has the id of the last attempt if it was sent again
+	Id flow.Identifier
+
+	builder    OverflowInteractionBuilder
+	result     *OverflowResult
+	logMessage []OverflowEmulatorLogMessage
+
+	// called once in WaitSealed after the result is complete
+	onSealed   func(*OverflowResult) *OverflowResult
+	sealedOnce sync.Once
+
+	mutex  sync.RWMutex
+	status flow.TransactionStatus
+	err    error
+
+	// closed when the status is reached or the transaction fails
+	finalized     chan struct{}
+	executed      chan struct{}
+	sealed        chan struct{}
+	finalizedOnce sync.Once
+	executedOnce  sync.Once
+}
+
+// SendAsync send the interaction as a transaction and return at once, the status is polled in the background until the transaction is sealed
+func (oib OverflowInteractionBuilder) SendAsync() *OverflowAsyncResult {
+	err := oib.applyAutoGas()
+	if err != nil {
+		oib.Error = err
+	}
+
+	async := &OverflowAsyncResult{
+		builder:   oib,
+		status:    flow.TransactionStatusUnknown,
+		finalized: make(chan struct{}),
+		executed:  make(chan struct{}),
+		sealed:    make(chan struct{}),
+	}
+
+	// a dry run is rolled back as soon as it has run so there is nothing to wait for
+	if oib.DryRun {
+		async.result = oib.sendDryRun()
+		async.Id = async.result.Id
+		if async.result.Err != nil {
+			async.fail(async.result.Err)
+			return async
+		}
+		async.release()
+		return async
+	}
+
+	sent := async.send(nil)
+	async.Id = async.result.Id
+	if !sent {
+		return async
+	}
+
+	go async.poll()
+	return async
+}
+
+// build, sign and send the transaction once without waiting for its result
+func (oib OverflowInteractionBuilder) sendAsyncOnce() (*OverflowResult, []OverflowEmulatorLogMessage) {
+	result, tx, pending := oib.buildSignedTransaction()
+	if result.Err != nil {
+		return result, nil
+	}
+
+	// the result is only known when the block is committed, so there is nothing to poll for
+	if pending.block != nil {
+		pending.done(false)
+		result.Err = fmt.Errorf("asynchronous transactions are not supported when blocks are committed manually, use Tx and CommitBlock")
+		return result, nil
+	}
+
+	// flowkit waits for the transaction to be sealed so we send it using the gateway directly
+	sent, err := oib.Overflow.Flowkit.Gateway().SendSignedTransaction(oib.Ctx, tx.FlowTransaction())
+	if err != nil {
+		pending.done(false)
+		result.Transaction = tx.FlowTransaction()
+		result.Err = err
+		return result, nil
+	}
+	result.Transaction = sent
+
+	// the emulator executes the transaction when it is sent so the log has to be read now before another transaction is sent
+	logMessage, err := oib.Overflow.readLog()
+	pending.done(true)
+	if err != nil {
+		result.Err = err
+	}
+	return result, logMessage
+}
+
+// TxAsync send a transaction without waiting for it to be sealed, printing and assertions are run when WaitSealed is called
+func (o *OverflowState) TxAsync(filename string, opts ...OverflowInteractionOption) *OverflowAsyncResult {
+	ftb := o.BuildInteraction(filename, "transaction", opts...)
+	async := ftb.SendAsync()
+	async.onSealed = func(result *OverflowResult) *OverflowResult {
+		return o.finishTx(ftb, result)
+	}
+	return async
+}
+
+// Status the last known status of the transaction
+func (a *OverflowAsyncResult) Status() flow.TransactionStatus {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.status
+}
+
+// WaitFinalized wait until the transaction is finalized, an error is returned if sending it or fetching its status failed
+func (a *OverflowAsyncResult) WaitFinalized() error {
+	<-a.finalized
+	return a.error()
+}
+
+// WaitExecuted wait until the transaction is executed, an error is returned if sending it or fetching its status failed
+func (a *OverflowAsyncResult) WaitExecuted() error {
+	<-a.executed
+	return a.error()
+}
+
+// WaitSealed wait until the transaction is sealed and return the full result with events, fees and meter
+func (a *OverflowAsyncResult) WaitSealed() *OverflowResult {
+	<-a.sealed
+	a.sealedOnce.Do(func() {
+		if a.result.Err != nil && a.result.StopOnError {
+			panic(a.result.Err)
+		}
+		if a.onSealed != nil {
+			a.result = a.onSealed(a.result)
+		}
+	})
+	return a.result
+}
+
+func (a *OverflowAsyncResult) error() error {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.err
+}
+
+// send the transaction and retry it like Send does, the errors of attempts that were made before count against the retries
+func (a *OverflowAsyncResult) send(attemptErrors []error) bool {
+	a.result = a.builder.retrySend(attemptErrors, func() *OverflowResult {
+		result, logMessage := a.builder.sendAsyncOnce()
+		a.logMessage = logMessage
+		// an attempt that failed does not get its fee recorded
+		if result.Err != nil {
+			result.sponsorReservation.settle(nil)
+		}
+		return result
+	})
+	if a.result.Err != nil {
+		a.fail(a.result.Err)
+		return false
+	}
+	a.setStatus(flow.TransactionStatusPending)
+	return true
+}
+
+// a transaction that failed on chain or expired with a retryable error did not change anything, so it can be sent again if there are attempts left
+func (a *OverflowAsyncResult) canResend(err error) bool {
+	a.result.Err = err
+	return len(a.result.AttemptErrors) < a.builder.RetryMax && a.builder.retryable(a.result)
+}
+
+// send the transaction again after it failed with the error, false if sending it failed
+func (a *OverflowAsyncResult) resend(err error) bool {
+	a.result.sponsorReservation.settle(nil)
+	return a.send(append(a.result.AttemptErrors, err))
+}
+
+// poll the status of the transaction until it is sealed or fails
+func (a *OverflowAsyncResult) poll() {
+	ctx := a.builder.Ctx
+	failures := 0
+	for {
+		id := a.result.Id
+		res, err := a.builder.Overflow.Flowkit.Gateway().GetTransactionResult(ctx, id, false)
+		switch {
+		case err != nil && isTransientError(err) && failures < a.builder.RetryMax:
+			failures++
+		case err != nil:
+			a.builder.Overflow.dropSequenceNumber(a.result.Transaction.ProposalKey)
+			a.fail(errors.Wrapf(err, "could not fetch status of transaction %s", id))
+			return
+		case res.Status == flow.TransactionStatusSealed:
+			if res.Error != nil {
+				a.builder.Overflow.dropSequenceNumber(a.result.Transaction.ProposalKey)
+			}
+			if res.Error != nil && a.canResend(res.Error) {
+				if !a.resend(res.Error) {
+					return
+				}
+				break
+			}
+			a.setStatus(res.Status)
+			a.complete(res)
+			return
+		case res.Status == flow.TransactionStatusExpired:
+			expired := fmt.Errorf("transaction %s is expired", id)
+			a.builder.Overflow.dropSequenceNumber(a.result.Transaction.ProposalKey)
+			if a.canResend(expired) {
+				if !a.resend(expired) {
+					return
+				}
+				break
+			}
+			a.setStatus(res.Status)
+			a.fail(expired)
+			return
+		default:
+			failures = 0
+			a.setStatus(res.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			a.fail(ctx.Err())
+			return
+		case <-time.After(a.builder.StatusPollInterval):
+		}
+	}
+}
+
+// move to the given status, the callback is called and waiters released for every status passed on the way
+func (a *OverflowAsyncResult) setStatus(status flow.TransactionStatus) {
+	a.mutex.Lock()
+	previous := a.status
+	if status <= previous {
+		a.mutex.Unlock()
+		return
+	}
+	a.status = status
+	a.mutex.Unlock()
+
+	if status == flow.TransactionStatusExpired {
+		if a.builder.StatusCallback != nil {
+			a.builder.StatusCallback(status)
+		}
+		return
+	}
+
+	for s := previous + 1; s <= status; s++ {
+		if a.builder.StatusCallback != nil {
+			a.builder.StatusCallback(s)
+		}
+		switch s {
+		case flow.TransactionStatusFinalized:
+			a.finalizedOnce.Do(func() { close(a.finalized) })
+		case flow.TransactionStatusExecuted:
+			a.executedOnce.Do(func() { close(a.executed) })
+		}
+	}
+}
+
+// turn the sealed transaction result into a full overflow result
+func (a *OverflowAsyncResult) complete(res *flow.TransactionResult) {
+	stopOnError := a.result.StopOnError
+
+	// we cannot panic in the background, WaitSealed does that instead
+	a.result.StopOnError = false
+	a.result.TransactionResult = res
+	a.result = a.builder.processResult(a.result, a.logMessage)
+	a.result.StopOnError = stopOnError
+
+	close(a.sealed)
+}
+
+// release all waiters with the given error
+func (a *OverflowAsyncResult) fail(err error) {
+	a.mutex.Lock()
+	a.err = err
+	a.mutex.Unlock()
+
+	a.result.Err = err
+	a.result.sponsorReservation.settle(nil)
+	a.release()
+}
+
+// release all waiters without changing the status
+func (a *OverflowAsyncResult) release() {
+	a.finalizedOnce.Do(func() { close(a.finalized) })
+	a.executedOnce.Do(func() { close(a.executed) })
+	close(a.sealed)
+}
+
+// call the function every time the status of a transaction sent with SendAsync or TxAsync changes
+func WithStatusCallback(callback func(flow.TransactionStatus)) OverflowInteractionOption {
+	return func(oib *OverflowInteractionBuilder) {
+		oib.StatusCallback = callback
+	}
+}
+
+// how often to poll for the status of a transaction sent with SendAsync or TxAsync
+func WithStatusPollInterval(interval time.Duration) OverflowInteractionOption {
+	return func(oib *OverflowInteractionBuilder) {
+		oib.StatusPollInterval = interval
+	}
+}
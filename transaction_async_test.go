@@ -0,0 +1,97 @@
+package overflow
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionAsync(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	t.Run("Send async and wait for all statuses", func(t *testing.T) {
+		var mutex sync.Mutex
+		statuses := []flow.TransactionStatus{}
+
+		async := o.TxAsync("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", 1.0),
+			WithStatusPollInterval(10*time.Millisecond),
+			WithStatusCallback(func(status flow.TransactionStatus) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				statuses = append(statuses, status)
+			}),
+		)
+
+		require.NoError(t, async.WaitFinalized())
+		require.NoError(t, async.WaitExecuted())
+		result := async.WaitSealed()
+		result.AssertSuccess(t).AssertEvent(t, "TokensMinted", map[string]interface{}{"amount": 1.0})
+		assert.Equal(t, async.Id, result.Id)
+		assert.NotEmpty(t, result.Fee)
+		assert.Equal(t, flow.TransactionStatusSealed, async.Status())
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.Equal(t, []flow.TransactionStatus{
+			flow.TransactionStatusPending,
+			flow.TransactionStatusFinalized,
+			flow.TransactionStatusExecuted,
+			flow.TransactionStatusSealed,
+		}, statuses)
+	})
+
+	t.Run("Send many and collect results later", func(t *testing.T) {
+		handles := []*OverflowAsyncResult{}
+		for _, amount := range []float64{1.0, 2.0, 3.0} {
+			handles = append(handles, o.BuildInteraction("mint_tokens", "transaction",
+				WithSignerServiceAccount(),
+				WithArg("recipient", "second"),
+				WithArg("amount", amount),
+				WithStatusPollInterval(10*time.Millisecond),
+			).SendAsync())
+		}
+
+		for i, handle := range handles {
+			handle.WaitSealed().AssertSuccess(t).AssertEvent(t, "TokensMinted", map[string]interface{}{"amount": float64(i + 1)})
+		}
+	})
+
+	t.Run("Send async runs assertions when sealed", func(t *testing.T) {
+		o.TxAsync("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", 1.0),
+			WithAssertEventWhere(t, "TokensMinted", EventField("/amount").Equals(1.0)),
+		).WaitSealed()
+	})
+
+	t.Run("Dry run async", func(t *testing.T) {
+		async := o.TxAsync("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", 1.0),
+			WithDryRun(),
+		)
+		require.NoError(t, async.WaitFinalized())
+		result := async.WaitSealed()
+		result.AssertSuccess(t).AssertEvent(t, "TokensMinted", map[string]interface{}{"amount": 1.0})
+		assert.True(t, result.DryRun)
+		assert.Equal(t, async.Id, result.Id)
+	})
+
+	t.Run("Send async with error", func(t *testing.T) {
+		async := o.TxAsync("create_nft_collection")
+		assert.ErrorContains(t, async.WaitFinalized(), "You need to set the proposer signer")
+		assert.ErrorContains(t, async.WaitExecuted(), "You need to set the proposer signer")
+		async.WaitSealed().AssertFailure(t, "You need to set the proposer signer")
+	})
+}
@@ -0,0 +1,71 @@
+package overflow
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// execute the transaction and roll back the state change, or only validate it if we are not running in memory
+func (oib OverflowInteractionBuilder) sendDryRun() *OverflowResult {
+	result, tx, pending := oib.buildSignedTransaction()
+	result.DryRun = true
+	if result.Err != nil {
+		return result
+	}
+	result.Transaction = tx.FlowTransaction()
+
+	// building the transaction has already validated the arguments and authorizers against the code
+	if oib.Overflow.EmulatorGatway == nil {
+		pending.done(false)
+		return result
+	}
+
+	// rolling back would throw away the transactions waiting in the pending block
+	if pending.block != nil {
+		pending.done(false)
+		result.Err = fmt.Errorf("dry runs are not supported when blocks are committed manually")
+		return result
+	}
+
+	// the emulator is held until it has been rolled back so no other transaction ends up in a block we throw away
+	block, err := oib.Overflow.Flowkit.Gateway().GetLatestBlock(oib.Ctx)
+	if err != nil {
+		pending.done(false)
+		result.Err = err
+		return result
+	}
+
+	_, err = oib.Overflow.Flowkit.Gateway().SendSignedTransaction(oib.Ctx, tx.FlowTransaction())
+	if err != nil {
+		pending.done(false)
+		result.Err = err
+		return result
+	}
+	logMessage, logErr := oib.Overflow.readLog()
+
+	res, err := oib.Overflow.Flowkit.Gateway().GetTransactionResult(oib.Ctx, result.Id, true)
+	rollbackErr := oib.Overflow.rollbackToBlockHeight(block.Height)
+	pending.done(false)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if rollbackErr != nil {
+		result.Err = errors.Wrapf(rollbackErr, "could not roll back dry run to height %d", block.Height)
+		return result
+	}
+
+	result.TransactionResult = res
+	if logErr != nil {
+		result.Err = logErr
+	}
+	return oib.processResult(result, logMessage)
+}
+
+// execute the transaction and throw away the state change afterwards, outside the in memory emulator the transaction is only built, signed and validated
+func WithDryRun() OverflowInteractionOption {
+	return func(oib *OverflowInteractionBuilder) {
+		oib.DryRun = true
+	}
+}
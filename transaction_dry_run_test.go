@@ -0,0 +1,76 @@
+package overflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	height := func(t *testing.T) uint64 {
+		block, err := o.GetLatestBlock(context.Background())
+		require.NoError(t, err)
+		return block.Height
+	}
+
+	mint := func(opts ...OverflowInteractionOption) *OverflowResult {
+		return o.Tx("mint_tokens", append([]OverflowInteractionOption{
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", 1.0),
+		}, opts...)...)
+	}
+
+	t.Run("Execute on emulator and roll back", func(t *testing.T) {
+		before := height(t)
+
+		result := mint(WithDryRun())
+		result.AssertSuccess(t).AssertEvent(t, "TokensMinted", map[string]interface{}{"amount": 1.0})
+		assert.True(t, result.DryRun)
+		assert.NotEmpty(t, result.Fee)
+		assert.NotEmpty(t, result.RawLog)
+		assert.Equal(t, before, height(t))
+
+		// the sequence number was not used up by the dry run
+		result = mint()
+		result.AssertSuccess(t)
+		assert.False(t, result.DryRun)
+		assert.Equal(t, before+1, height(t))
+	})
+
+	t.Run("Failing dry run", func(t *testing.T) {
+		before := height(t)
+		mint(WithDryRun(), WithArg("recipient", "foobar")).AssertFailure(t, "contract foobar does not exist")
+		o.Tx(`transaction { prepare(signer: &Account) { panic("oops") } }`, WithSignerServiceAccount(), WithDryRun()).AssertFailure(t, "oops")
+		assert.Equal(t, before, height(t))
+	})
+
+	t.Run("Validate authorizers", func(t *testing.T) {
+		result := o.Tx("signWithMultipleAccounts", WithSigner("first"), WithArg("test", "foo"), WithDryRun())
+		assert.ErrorContains(t, result.Err, "required authorizers 2, but provided 1")
+	})
+
+	t.Run("Only validate outside the emulator", func(t *testing.T) {
+		before := height(t)
+
+		network := *o
+		network.EmulatorGatway = nil
+		result := network.Tx("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", 1.0),
+			WithDryRun(),
+		)
+		assert.NoError(t, result.Err)
+		assert.True(t, result.DryRun)
+		assert.Empty(t, result.Events)
+		assert.NotEmpty(t, result.Transaction.EnvelopeSignatures)
+		assert.Equal(t, before, height(t))
+	})
+}
@@ -0,0 +1,343 @@
+package overflow
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bjartek/underflow"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flowkit/v2/accounts"
+	"github.com/onflow/flowkit/v2/transactions"
+	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+)
+
+// OverflowOfflineTransaction a transaction that is built but might not be fully signed yet.
+// It can be passed around as RLP hex or a json envelope so that every party signs it with their own keys, IE
+//
+//	tx, _ := o.BuildOfflineTx("admin_transaction", WithPayloadSigner("admin1"), WithPayloadSigner("admin2"), WithPayer("payer"))
+//	os.WriteFile("tx.json", tx.MustEnvelope(), 0644)
+//
+//	// on the machine of every party
+//	tx, _ := ParseOfflineTransaction(content)
+//	o.SignOfflineTransaction(tx, "admin1")
+//
+//	o.SendOfflineTransaction(tx)
+type OverflowOfflineTransaction struct {
+	// the name of the file the code was read from
+	Name        string
+	Transaction *flow.Transaction
+}
+
+// OverflowTransactionEnvelope the json form of an offline transaction, the fields besides Rlp are there so parties can see what they sign
+type OverflowTransactionEnvelope struct {
+	Id                 string                      `json:"id"`
+	Name               string                      `json:"name"`
+	Rlp                string                      `json:"rlp"`
+	Code               string                      `json:"code"`
+	Arguments          map[string]interface{}      `json:"arguments"`
+	ReferenceBlockId   string                      `json:"referenceBlockId"`
+	GasLimit           uint64                      `json:"gasLimit"`
+	Proposer           OverflowEnvelopeProposalKey `json:"proposer"`
+	Authorizers        []string                    `json:"authorizers"`
+	Payer              string                      `json:"payer"`
+	PayloadSignatures  []OverflowEnvelopeSignature `json:"payloadSignatures"`
+	EnvelopeSignatures []OverflowEnvelopeSignature `json:"envelopeSignatures"`
+	MissingSignatures  []string                    `json:"missingSignatures"`
+}
+
+// OverflowEnvelopeProposalKey the proposal key of an offline transaction
+type OverflowEnvelopeProposalKey struct {
+	Address        string `json:"address"`
+	KeyIndex       uint32 `json:"keyIndex"`
+	SequenceNumber uint64 `json:"sequenceNumber"`
+}
+
+// OverflowEnvelopeSignature a signature that is present on an offline transaction
+type OverflowEnvelopeSignature struct {
+	Address  string `json:"address"`
+	KeyIndex uint32 `json:"keyIndex"`
+}
+
+// Build the transaction without signing it, the proposer, payer and payload signers only need addresses and key indexes
+func (oib OverflowInteractionBuilder) Build() (*OverflowOfflineTransaction, error) {
+	result, tx, _, pending := oib.buildTransaction()
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	// a sequence number is only used up once the transaction is sent and that is not up to us
+	pending.done(false)
+
+	return &OverflowOfflineTransaction{
+		Name:        oib.FileName,
+		Transaction: tx.FlowTransaction(),
+	}, nil
+}
+
+// BuildOfflineTx build a transaction from the given file to be signed offline
+func (o *OverflowState) BuildOfflineTx(filename string, opts ...OverflowInteractionOption) (*OverflowOfflineTransaction, error) {
+	return o.BuildInteraction(filename, "transaction", opts...).Build()
+}
+
+// ParseOfflineTransaction parse a transaction from either RLP hex or a json envelope
+func ParseOfflineTransaction(content string) (*OverflowOfflineTransaction, error) {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "{") {
+		tx := &OverflowOfflineTransaction{}
+		err := json.Unmarshal([]byte(content), tx)
+		if err != nil {
+			return nil, err
+		}
+		return tx, nil
+	}
+
+	tx, err := decodeOfflineTransaction(content)
+	if err != nil {
+		return nil, err
+	}
+	return &OverflowOfflineTransaction{Name: "offline", Transaction: tx}, nil
+}
+
+func decodeOfflineTransaction(rlp string) (*flow.Transaction, error) {
+	bytes, err := hex.DecodeString(strings.TrimPrefix(rlp, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode transaction hex")
+	}
+	tx, err := flow.DecodeTransaction(bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode transaction")
+	}
+	return tx, nil
+}
+
+// Encode the transaction with the signatures it has as RLP hex
+func (t *OverflowOfflineTransaction) Encode() string {
+	return hex.EncodeToString(t.Transaction.Encode())
+}
+
+// NamedArguments the arguments of the transaction by the name of their parameter
+func (t *OverflowOfflineTransaction) NamedArguments() (CadenceArguments, error) {
+	info := declarationInfo(t.Transaction.Script)
+	if len(info.ParameterOrder) != len(t.Transaction.Arguments) {
+		return nil, fmt.Errorf("transaction has %d arguments but %d parameters", len(t.Transaction.Arguments), len(info.ParameterOrder))
+	}
+
+	arguments := CadenceArguments{}
+	for i, name := range info.ParameterOrder {
+		value, err := t.Transaction.Argument(i)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decode argument %s", name)
+		}
+		arguments[name] = value
+	}
+	return arguments, nil
+}
+
+// MissingSignatures the addresses that still have to sign, payload signers first and the payer last
+func (t *OverflowOfflineTransaction) MissingSignatures() []string {
+	tx := t.Transaction
+	signed := func(signatures []flow.TransactionSignature, address flow.Address) bool {
+		return slices.ContainsFunc(signatures, func(s flow.TransactionSignature) bool { return s.Address == address })
+	}
+
+	missing := []string{}
+	payloadSigners := append([]flow.Address{tx.ProposalKey.Address}, tx.Authorizers...)
+	for _, address := range payloadSigners {
+		if address == tx.Payer || signed(tx.PayloadSignatures, address) || slices.Contains(missing, address.HexWithPrefix()) {
+			continue
+		}
+		missing = append(missing, address.HexWithPrefix())
+	}
+	if !signed(tx.EnvelopeSignatures, tx.Payer) {
+		missing = append(missing, tx.Payer.HexWithPrefix())
+	}
+	return missing
+}
+
+// Sign the transaction with the key of the account, the payer signs the envelope and everybody else the payload
+func (t *OverflowOfflineTransaction) Sign(account *accounts.Account) error {
+	tx := t.Transaction
+	if account.Address != tx.Payer && len(tx.EnvelopeSignatures) != 0 {
+		return fmt.Errorf("cannot add payload signature for %s since the payer has already signed the envelope", account.Address.HexWithPrefix())
+	}
+
+	ftx := transactions.New()
+	*ftx.FlowTransaction() = *tx
+	err := ftx.SetSigner(account)
+	if err != nil {
+		return err
+	}
+	ftx, err = ftx.Sign()
+	if err != nil {
+		return err
+	}
+	t.Transaction = ftx.FlowTransaction()
+	return nil
+}
+
+// Envelope the json envelope of the transaction
+func (t *OverflowOfflineTransaction) Envelope() (*OverflowTransactionEnvelope, error) {
+	tx := t.Transaction
+	arguments, err := t.NamedArguments()
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := &OverflowTransactionEnvelope{
+		Id:               tx.ID().String(),
+		Name:             t.Name,
+		Rlp:              t.Encode(),
+		Code:             string(tx.Script),
+		Arguments:        map[string]interface{}{},
+		ReferenceBlockId: tx.ReferenceBlockID.String(),
+		GasLimit:         tx.GasLimit,
+		Proposer: OverflowEnvelopeProposalKey{
+			Address:        tx.ProposalKey.Address.HexWithPrefix(),
+			KeyIndex:       tx.ProposalKey.KeyIndex,
+			SequenceNumber: tx.ProposalKey.SequenceNumber,
+		},
+		Authorizers:        []string{},
+		Payer:              tx.Payer.HexWithPrefix(),
+		PayloadSignatures:  envelopeSignatures(tx.PayloadSignatures),
+		EnvelopeSignatures: envelopeSignatures(tx.EnvelopeSignatures),
+		MissingSignatures:  t.MissingSignatures(),
+	}
+	for name, value := range arguments {
+		envelope.Arguments[name] = underflow.CadenceValueToInterface(value)
+	}
+	for _, authorizer := range tx.Authorizers {
+		envelope.Authorizers = append(envelope.Authorizers, authorizer.HexWithPrefix())
+	}
+	return envelope, nil
+}
+
+func envelopeSignatures(signatures []flow.TransactionSignature) []OverflowEnvelopeSignature {
+	result := []OverflowEnvelopeSignature{}
+	for _, signature := range signatures {
+		result = append(result, OverflowEnvelopeSignature{Address: signature.Address.HexWithPrefix(), KeyIndex: signature.KeyIndex})
+	}
+	return result
+}
+
+// MustEnvelope the json envelope of the transaction indented for humans, panics on error
+func (t *OverflowOfflineTransaction) MustEnvelope() []byte {
+	bytes, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return bytes
+}
+
+// MarshalJSON marshal the transaction as its json envelope
+func (t *OverflowOfflineTransaction) MarshalJSON() ([]byte, error) {
+	envelope, err := t.Envelope()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope)
+}
+
+// UnmarshalJSON read the transaction from the RLP in a json envelope, the other fields are only informational
+func (t *OverflowOfflineTransaction) UnmarshalJSON(data []byte) error {
+	var envelope OverflowTransactionEnvelope
+	err := json.Unmarshal(data, &envelope)
+	if err != nil {
+		return err
+	}
+	tx, err := decodeOfflineTransaction(envelope.Rlp)
+	if err != nil {
+		return err
+	}
+	t.Name = envelope.Name
+	t.Transaction = tx
+	return nil
+}
+
+// SignOfflineTransaction sign the transaction with the account with the given name
+func (o *OverflowState) SignOfflineTransaction(tx *OverflowOfflineTransaction, accountName string) error {
+	account, err := o.accountByName(accountName)
+	if err != nil {
+		return err
+	}
+	return tx.Sign(account)
+}
+
+// SendOfflineTransaction send a fully signed transaction and wait for it to be sealed, the options are used for printing and assertions
+func (o *OverflowState) SendOfflineTransaction(tx *OverflowOfflineTransaction, opts ...OverflowInteractionOption) *OverflowResult {
+	ftb := o.newInteractionBuilder(o.TransactionBasePath, opts)
+	ftb.FileName = tx.Name
+	ftb.TransactionCode = tx.Transaction.Script
+	if ftb.Name == "" {
+		ftb.Name = tx.Name
+	}
+	return o.finishTx(ftb, ftb.sendOffline(tx))
+}
+
+func (oib OverflowInteractionBuilder) sendOffline(offline *OverflowOfflineTransaction) *OverflowResult {
+	result := &OverflowResult{
+		StopOnError:      oib.Overflow.StopOnError,
+		Id:               offline.Transaction.ID(),
+		Meter:            &OverflowMeter{},
+		RawLog:           []OverflowEmulatorLogMessage{},
+		EmulatorLog:      []string{},
+		RawEvents:        []flow.Event{},
+		Events:           map[string]OverflowEventList{},
+		Transaction:      offline.Transaction,
+		Fee:              map[string]interface{}{},
+		Name:             oib.Name,
+		UnderflowOptions: oib.Overflow.UnderflowOptions,
+		DeclarationInfo:  *declarationInfo(offline.Transaction.Script),
+	}
+	result.sponsored = oib.Overflow.isSponsored(offline.Transaction)
+	if oib.StopOnError != nil {
+		result.StopOnError = *oib.StopOnError
+	}
+	if oib.Error != nil {
+		result.Err = oib.Error
+		return result
+	}
+
+	arguments, err := offline.NamedArguments()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Arguments = arguments
+
+	if missing := offline.MissingSignatures(); len(missing) != 0 {
+		result.Err = fmt.Errorf("transaction %s is missing signatures from %s", result.Id, strings.Join(missing, ", "))
+		return result
+	}
+
+	unlock := oib.Overflow.lockEmulatorLog()
+	_, err = oib.Overflow.Flowkit.Gateway().SendSignedTransaction(oib.Ctx, offline.Transaction)
+	if err != nil {
+		unlock()
+		result.Err = err
+		return result
+	}
+	logMessage, logErr := oib.Overflow.readLog()
+
+	// the transaction is executed when the block is committed, CommitBlock fills in the result
+	if block := oib.Overflow.pendingBlock; block != nil {
+		block.useSequenceNumber(offline.Transaction.ProposalKey)
+		result.Pending = true
+		block.add(&oib, result)
+		unlock()
+		return result
+	}
+	unlock()
+
+	res, err := oib.Overflow.Flowkit.Gateway().GetTransactionResult(oib.Ctx, result.Id, true)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.TransactionResult = res
+	if logErr != nil {
+		result.Err = logErr
+	}
+	return oib.processResult(result, logMessage)
+}
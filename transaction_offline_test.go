@@ -0,0 +1,103 @@
+package overflow
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfflineTransaction(t *testing.T) {
+	o, err := OverflowTesting()
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	code := `
+transaction(message: String) {
+	prepare(first: &Account, second: &Account) {
+		log(message)
+	}
+}`
+
+	build := func(t *testing.T) *OverflowOfflineTransaction {
+		tx, err := o.BuildOfflineTx(code,
+			WithProposerServiceAccount(),
+			WithPayloadSigner("first", "second"),
+			WithArg("message", "multisig"),
+		)
+		require.NoError(t, err)
+		return tx
+	}
+
+	t.Run("Sign on separate machines and send", func(t *testing.T) {
+		tx := build(t)
+
+		envelope, err := tx.Envelope()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"message": "multisig"}, envelope.Arguments)
+		assert.Equal(t, []string{o.Address("first"), o.Address("second")}, envelope.Authorizers)
+		assert.Equal(t, []string{o.Address("first"), o.Address("second"), o.Address("account")}, envelope.MissingSignatures)
+
+		// the first party gets the json envelope
+		firstTx, err := ParseOfflineTransaction(string(tx.MustEnvelope()))
+		require.NoError(t, err)
+		require.NoError(t, o.SignOfflineTransaction(firstTx, "first"))
+
+		// the second party gets the hex
+		secondTx, err := ParseOfflineTransaction(firstTx.Encode())
+		require.NoError(t, err)
+		require.NoError(t, o.SignOfflineTransaction(secondTx, "second"))
+		assert.Equal(t, []string{o.Address("account")}, secondTx.MissingSignatures())
+
+		require.NoError(t, o.SignOfflineTransaction(secondTx, o.ServiceAccountName()))
+		assert.Empty(t, secondTx.MissingSignatures())
+
+		result := o.SendOfflineTransaction(secondTx)
+		result.AssertSuccess(t).AssertEmulatorLog(t, "multisig")
+		assert.Equal(t, secondTx.Transaction.ID(), result.Id)
+		assert.Equal(t, cadence.String("multisig"), result.Arguments["message"])
+	})
+
+	t.Run("Envelope roundtrips through json", func(t *testing.T) {
+		tx := build(t)
+		require.NoError(t, o.SignOfflineTransaction(tx, "first"))
+
+		bytes, err := json.Marshal(tx)
+		require.NoError(t, err)
+
+		var parsed OverflowOfflineTransaction
+		require.NoError(t, json.Unmarshal(bytes, &parsed))
+		assert.Equal(t, tx.Encode(), parsed.Encode())
+		assert.Equal(t, tx.Name, parsed.Name)
+	})
+
+	t.Run("Cannot send with missing signatures", func(t *testing.T) {
+		tx := build(t)
+		require.NoError(t, o.SignOfflineTransaction(tx, "first"))
+
+		result := o.SendOfflineTransaction(tx)
+		assert.ErrorContains(t, result.Err, "is missing signatures from "+o.Address("second")+", "+o.Address("account"))
+	})
+
+	t.Run("Cannot sign payload after envelope", func(t *testing.T) {
+		tx := build(t)
+		require.NoError(t, o.SignOfflineTransaction(tx, o.ServiceAccountName()))
+
+		err := o.SignOfflineTransaction(tx, "first")
+		assert.ErrorContains(t, err, "since the payer has already signed the envelope")
+	})
+
+	t.Run("Cannot sign with account that is not part of the transaction", func(t *testing.T) {
+		tx, err := o.BuildOfflineTx(code,
+			WithProposerServiceAccount(),
+			WithPayloadSigner("first", "first"),
+			WithArg("message", "multisig"),
+		)
+		require.NoError(t, err)
+
+		err = o.SignOfflineTransaction(tx, "second")
+		assert.ErrorContains(t, err, "not a valid signer")
+	})
+}
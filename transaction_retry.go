@@ -0,0 +1,147 @@
+package overflow
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the gRPC codes that are worth trying again
+var retryableCodes = []codes.Code{
+	codes.Unavailable,
+	codes.DeadlineExceeded,
+	codes.ResourceExhausted,
+	codes.Aborted,
+}
+
+// IsRetryableError reports if a transaction that failed with the error can succeed if it is built and sent again,
+// that is sequence number mismatches, expired reference blocks and transient gRPC errors
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if isSequenceNumberError(err) {
+		return true
+	}
+
+	// 1003 is an expired and 1002 an invalid reference block, see flow-go fvm/errors/codes.go
+	message := err.Error()
+	if strings.Contains(message, "[Error Code: 1003]") || strings.Contains(message, "[Error Code: 1002]") || strings.Contains(message, "transaction is expired") {
+		return true
+	}
+	return isTransientError(err)
+}
+
+// a gRPC error that may go away if the call is made again
+func isTransientError(err error) bool {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if s, ok := status.FromError(e); ok && s.Code() != codes.Unknown && s.Code() != codes.OK {
+			for _, code := range retryableCodes {
+				if s.Code() == code {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return false
+}
+
+func isSequenceNumberError(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "[Error Code: 1007]") || strings.Contains(message, "sequence number")
+}
+
+// send the transaction, retrying the number of times configured with WithRetry or WithInteractionRetry
+func (oib OverflowInteractionBuilder) sendWithRetry() *OverflowResult {
+	return oib.retrySend(nil, oib.sendOnce)
+}
+
+// send the transaction with the function until it is accepted or fails with an error that is not retryable,
+// the errors of attempts that were made before count against the retries
+func (oib OverflowInteractionBuilder) retrySend(attemptErrors []error, send func() *OverflowResult) *OverflowResult {
+	var lastErr error
+	first := true
+	retries := max(oib.RetryMax-len(attemptErrors), 0)
+	result, _ := retryWithBackoff(oib.Ctx, retries, oib.RetryBackoff<<len(attemptErrors), func() (*OverflowResult, error) {
+		if !first {
+			attemptErrors = append(attemptErrors, lastErr)
+		}
+		first = false
+
+		result := send()
+		lastErr = result.Err
+		if !oib.retryable(result) {
+			return result, nil
+		}
+		return result, result.Err
+	})
+
+	result.Attempts = len(attemptErrors) + 1
+	result.AttemptErrors = attemptErrors
+	return result
+}
+
+// if the transaction can be sent again after it failed
+func (oib OverflowInteractionBuilder) retryable(result *OverflowResult) bool {
+	// a transaction that was accepted could run twice if it was sent again
+	if result.resultUnknown || !IsRetryableError(result.Err) {
+		return false
+	}
+
+	// the sequence number we tracked for the key is wrong so the next attempt takes it from chain
+	if isSequenceNumberError(result.Err) && result.Transaction != nil {
+		oib.Overflow.forgetSequenceNumber(result.Transaction.ProposalKey)
+	}
+	return true
+}
+
+// wait for the result of a transaction that has been sent, transient errors are retried like sending is
+func (oib OverflowInteractionBuilder) waitForResult(id flow.Identifier) (*flow.TransactionResult, error) {
+	var permanentErr error
+	res, err := retryWithBackoff(oib.Ctx, oib.RetryMax, oib.RetryBackoff, func() (*flow.TransactionResult, error) {
+		res, err := oib.Overflow.Flowkit.Gateway().GetTransactionResult(oib.Ctx, id, true)
+		if err != nil && !isTransientError(err) {
+			permanentErr = err
+			return nil, nil
+		}
+		return res, err
+	})
+	if permanentErr != nil {
+		return nil, permanentErr
+	}
+	return res, err
+}
+
+// stop tracking the sequence number of the key if it is in a proposer key pool
+func (o *OverflowState) forgetSequenceNumber(key flow.ProposalKey) {
+	pool, ok := o.ProposerKeyPools[key.Address.HexWithPrefix()]
+	if !ok {
+		return
+	}
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	delete(pool.sequenceNumbers, key.KeyIndex)
+}
+
+// retry sending transactions that fail with a retryable error up to max times, waiting backoff before the first retry and doubling it for every retry after that.
+// Every attempt builds the transaction again so it gets a fresh sequence number and reference block and is signed again,
+// once a transaction is accepted it is never sent again and only fetching its result is retried
+func WithRetry(max int, backoff time.Duration) OverflowOption {
+	return func(o *OverflowBuilder) {
+		o.RetryMax = max
+		o.RetryBackoff = backoff
+	}
+}
+
+// retry sending this transaction if it fails with a retryable error, overrides WithRetry
+func WithInteractionRetry(max int, backoff time.Duration) OverflowInteractionOption {
+	return func(oib *OverflowInteractionBuilder) {
+		oib.RetryMax = max
+		oib.RetryBackoff = backoff
+	}
+}
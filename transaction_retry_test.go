@@ -0,0 +1,158 @@
+package overflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flowkit/v2"
+	"github.com/onflow/flowkit/v2/gateway"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		err       error
+		retryable bool
+	}{
+		{nil, false},
+		{status.Error(codes.Unavailable, "connection refused"), true},
+		{errors.Wrap(status.Error(codes.DeadlineExceeded, "timeout"), "could not send"), true},
+		{status.Error(codes.InvalidArgument, "bad transaction"), false},
+		{fmt.Errorf("transaction is expired: ref_height=6 final_height=7"), true},
+		{fmt.Errorf("[Error Code: 1003] transaction is expired: ref_height=6 final_height=7"), true},
+		{fmt.Errorf("[Error Code: 1002] invalid reference block"), true},
+		{fmt.Errorf("[Error Code: 1007] invalid proposal key: public key 0 on account f8d6e0586b0a20c7 has sequence number 1000, but given 3"), true},
+		{fmt.Errorf("[Error Code: 1006] invalid proposal key: public key 0 on account f8d6e0586b0a20c7 does not have a valid signature"), false},
+		{fmt.Errorf("[Error Code: 1101] error caused by: cadence runtime error: panic: oops"), false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.retryable, IsRetryableError(test.err), "%v", test.err)
+	}
+}
+
+func TestTransactionRetry(t *testing.T) {
+	o, err := OverflowTesting(WithProposerKeyPool("account", 0), WithRetry(2, time.Millisecond))
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	pool := o.ProposerKeyPools[o.Address("account")]
+	require.NotNil(t, pool)
+	breakSequenceNumber := func() {
+		pool.mutex.Lock()
+		defer pool.mutex.Unlock()
+		pool.sequenceNumbers[0] = 1000
+	}
+
+	t.Run("Recover from wrong sequence number", func(t *testing.T) {
+		breakSequenceNumber()
+		result := o.Tx("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", 1.0),
+		)
+		result.AssertSuccess(t)
+		assert.Equal(t, 2, result.Attempts)
+		require.Len(t, result.AttemptErrors, 1)
+		assert.True(t, isSequenceNumberError(result.AttemptErrors[0]))
+	})
+
+	t.Run("Recover async transaction from wrong sequence number", func(t *testing.T) {
+		breakSequenceNumber()
+		async := o.TxAsync("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", 1.0),
+			WithStatusPollInterval(10*time.Millisecond),
+		)
+		result := async.WaitSealed()
+		result.AssertSuccess(t)
+		assert.Equal(t, 2, result.Attempts)
+		require.Len(t, result.AttemptErrors, 1)
+		assert.True(t, isSequenceNumberError(result.AttemptErrors[0]))
+		assert.NotEqual(t, async.Id, result.Id)
+	})
+
+	t.Run("Do not retry without retries", func(t *testing.T) {
+		breakSequenceNumber()
+		result := o.Tx("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", 1.0),
+			WithInteractionRetry(0, 0),
+		)
+		assert.True(t, IsRetryableError(result.Err))
+		assert.Equal(t, 1, result.Attempts)
+		assert.Empty(t, result.AttemptErrors)
+	})
+
+	t.Run("Do not retry failing transactions", func(t *testing.T) {
+		result := o.Tx(`transaction { prepare(signer: &Account) { panic("oops") } }`, WithSignerServiceAccount())
+		result.AssertFailure(t, "oops")
+		assert.Equal(t, 1, result.Attempts)
+	})
+}
+
+// a gateway that fails fetching the result of transactions the given number of times
+type failingResultGateway struct {
+	gateway.Gateway
+	failures int
+	err      error
+}
+
+func (g *failingResultGateway) GetTransactionResult(ctx context.Context, id flow.Identifier, waitSeal bool) (*flow.TransactionResult, error) {
+	if g.failures > 0 {
+		g.failures--
+		return nil, g.err
+	}
+	return g.Gateway.GetTransactionResult(ctx, id, waitSeal)
+}
+
+func TestTransactionRetryResult(t *testing.T) {
+	o, err := OverflowTesting(WithRetry(2, time.Millisecond))
+	require.NoError(t, err)
+	require.NotNil(t, o)
+
+	balance := func(t *testing.T) float64 {
+		value, err := o.Script(`access(all) fun main(address: Address): UFix64 { return getAccount(address).balance }`, WithArg("address", "first")).GetAsInterface()
+		require.NoError(t, err)
+		return value.(float64)
+	}
+
+	failResults := func(failures int, err error) {
+		o.Flowkit = flowkit.NewFlowkit(o.State, o.Network, &failingResultGateway{Gateway: o.EmulatorGatway, failures: failures, err: err}, o.Logger)
+	}
+
+	mint := func() *OverflowResult {
+		return o.Tx("mint_tokens",
+			WithSignerServiceAccount(),
+			WithArg("recipient", "first"),
+			WithArg("amount", 1.0),
+		)
+	}
+
+	t.Run("Only fetch result again after it fails", func(t *testing.T) {
+		start := balance(t)
+		failResults(1, status.Error(codes.Unavailable, "connection refused"))
+		result := mint()
+		result.AssertSuccess(t)
+		assert.Equal(t, 1, result.Attempts)
+		assert.InDelta(t, start+1.0, balance(t), 0.0001)
+	})
+
+	t.Run("Do not send again when result cannot be fetched", func(t *testing.T) {
+		start := balance(t)
+		failResults(3, status.Error(codes.DeadlineExceeded, "timeout"))
+		result := mint()
+		assert.ErrorContains(t, result.Err, "was sent but its result could not be fetched")
+		assert.Equal(t, 1, result.Attempts)
+		assert.InDelta(t, start+1.0, balance(t), 0.0001)
+	})
+}